@@ -0,0 +1,318 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/maximerauch/go-classifieds-watcher/internal/adapters/composite"
+	"github.com/maximerauch/go-classifieds-watcher/internal/adapters/email"
+	"github.com/maximerauch/go-classifieds-watcher/internal/adapters/recorder"
+	"github.com/maximerauch/go-classifieds-watcher/internal/config"
+	"github.com/maximerauch/go-classifieds-watcher/internal/core"
+	"github.com/maximerauch/go-classifieds-watcher/internal/scheduler"
+)
+
+// source is a watched provider along with its settings.
+type source struct {
+	key          string // Name of the source in the configuration
+	provider     core.Provider
+	filter       core.Filter
+	schedule     string        // Schedule as configured, e.g. "@every 15m"
+	dataFilePath string        // File of its items with the json repository backend
+	job          scheduler.Job // Schedule of the provider, without its Run function
+}
+
+// app holds the components built from the configuration, shared by every command.
+// Building it validates the configuration without connecting to anything.
+type app struct {
+	cfg           config.AppConfig
+	logger        *slog.Logger
+	sources       []source
+	channels      []core.Channel // Channels of the default subscription
+	notifier      core.Notifier
+	subscriptions []core.Subscription
+	changePolicy  core.ChangePolicy
+	bootstrapMode core.BootstrapMode
+	retryPolicy   core.RetryPolicy
+}
+
+// newApp builds the components of the configuration. emailOpts apply to every email notifier.
+func newApp(cfg config.AppConfig, logger *slog.Logger, emailOpts ...email.Option) (*app, error) {
+	a := &app{cfg: cfg, logger: logger}
+
+	// Setup Notifiers: each enabled one is tracked as an outbox channel
+	channels, err := buildChannels(cfg.Watcher.Notifiers, notifierEnv{logger: logger, email: cfg.Email, emailOpts: emailOpts})
+	if err != nil {
+		return nil, err
+	}
+	if len(channels) == 0 {
+		return nil, errors.New("no notifier enabled")
+	}
+	a.channels = channels
+	a.notifier = compose(channels)
+
+	a.retryPolicy = core.RetryPolicy{
+		MaxAttempts: cfg.Outbox.MaxAttempts,
+		BaseDelay:   cfg.Outbox.BaseDelay,
+		MaxDelay:    cfg.Outbox.MaxDelay,
+	}
+
+	// Setup Providers: every enabled source and watch is scanned by the same daemon, with its own filter rules
+	providerNames := make(map[string]string)
+	seen := make(map[string]bool)
+	for _, w := range cfg.Sources() {
+		factory, ok := providerFactories[w.Type]
+		if !ok {
+			return nil, fmt.Errorf("unknown provider %q, want one of %v", w.Type, slices.Sorted(maps.Keys(providerFactories)))
+		}
+		provider := factory(w, logger)
+		name := provider.Name()
+		if seen[name] {
+			return nil, fmt.Errorf("provider %q: items of %s are already stored by another source", w.Name, name)
+		}
+
+		filter, err := buildFilter(w.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter for %s: %w", name, err)
+		}
+		schedule, err := scheduler.Parse(w.Schedule.Spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule for %s: %w", name, err)
+		}
+
+		providerNames[w.Name] = name
+		seen[name] = true
+		a.sources = append(a.sources, source{
+			key:          w.Name,
+			provider:     provider,
+			filter:       filter,
+			schedule:     w.Schedule.Spec,
+			dataFilePath: w.DataFilePath,
+			job: scheduler.Job{
+				Name:     name,
+				Schedule: schedule,
+				Jitter:   w.Schedule.Jitter,
+				Timeout:  w.Schedule.Timeout,
+			},
+		})
+	}
+	if len(a.sources) == 0 {
+		return nil, errors.New("no provider enabled")
+	}
+
+	if a.subscriptions, err = buildSubscriptions(cfg, logger, providerNames, emailOpts); err != nil {
+		return nil, err
+	}
+	if a.changePolicy, err = core.ParseChangePolicy(cfg.Watcher.ChangeNotifications); err != nil {
+		return nil, err
+	}
+	if a.bootstrapMode, err = core.ParseBootstrapMode(cfg.Watcher.Bootstrap); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// service creates the domain service watching every source, persisting to repo.
+// Without outbox, notifications are sent directly and quiet hours are not applied.
+func (a *app) service(repo core.Repository, outbox core.Outbox) *core.WatcherService {
+	providers := make([]core.Provider, len(a.sources))
+	filters := make(map[string]core.Filter)
+	for i, s := range a.sources {
+		providers[i] = s.provider
+		filters[s.provider.Name()] = s.filter
+	}
+
+	opts := []core.Option{
+		core.WithConcurrency(a.cfg.Watcher.ProviderConcurrency),
+		core.WithEnrichConcurrency(a.cfg.Watcher.EnrichConcurrency),
+		core.WithChangePolicy(a.changePolicy),
+		core.WithFilters(filters),
+		core.WithRemovalDetection(a.cfg.Watcher.RemovalThreshold, a.cfg.Watcher.NotifyRemovals),
+		core.WithBootstrap(a.bootstrapMode, a.cfg.Watcher.BootstrapSummary),
+		core.WithSubscriptions(a.subscriptions...),
+	}
+	if outbox != nil {
+		opts = append(opts, core.WithOutbox(outbox, a.retryPolicy, a.channels...), core.WithOutboxRetention(a.cfg.Outbox.Retention))
+	}
+	return core.NewWatcherService(providers, repo, a.notifier, a.logger, opts...)
+}
+
+// openRepository opens the configured storage backend, with its outbox.
+func (a *app) openRepository() (core.Repository, core.Outbox, error) {
+	dataFiles := make(map[string]string)
+	for _, s := range a.sources {
+		if s.dataFilePath != "" {
+			dataFiles[s.provider.Name()] = s.dataFilePath
+		}
+	}
+	factory, ok := repositoryFactories[a.cfg.Repository.Backend]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown repository backend %q, want one of %v", a.cfg.Repository.Backend, slices.Sorted(maps.Keys(repositoryFactories)))
+	}
+	return factory(a.cfg, dataFiles)
+}
+
+// compose merges channels into the notifier of the service.
+func compose(channels []core.Channel) core.Notifier {
+	notifiers := make([]core.Notifier, len(channels))
+	for i, ch := range channels {
+		notifiers[i] = ch.Notifier
+	}
+	return composite.NewCompositeNotifier(notifiers...)
+}
+
+// recordedChannel is a channel whose notifications are recorded by a dry run.
+type recordedChannel struct {
+	name     string
+	notifier *recorder.Notifier
+}
+
+// record wraps every channel with a recorder, rendering through the original notifier.
+// It must be called before building the service.
+func (a *app) record() []recordedChannel {
+	var recorded []recordedChannel
+	wrap := func(prefix string, channels []core.Channel) {
+		for i, ch := range channels {
+			rec := recorder.NewNotifier(ch.Notifier)
+			channels[i].Notifier = rec
+			recorded = append(recorded, recordedChannel{name: prefix + ch.Name, notifier: rec})
+		}
+	}
+
+	wrap("", a.channels)
+	a.notifier = compose(a.channels)
+	for _, sub := range a.subscriptions {
+		wrap(sub.Name+"/", sub.Channels)
+	}
+	return recorded
+}
+
+// source returns a source by configuration key or provider name.
+func (a *app) source(name string) (source, bool) {
+	for _, s := range a.sources {
+		if s.key == name || s.provider.Name() == name {
+			return s, true
+		}
+	}
+	return source{}, false
+}
+
+// allChannels returns the channels of every subscription, or the default ones when none is configured.
+func (a *app) allChannels() []core.Channel {
+	if len(a.subscriptions) == 0 {
+		return a.channels
+	}
+	var channels []core.Channel
+	for _, sub := range a.subscriptions {
+		for _, ch := range sub.Channels {
+			ch.Name = sub.Name + "/" + ch.Name
+			channels = append(channels, ch)
+		}
+	}
+	return channels
+}
+
+// runTimeout returns the longest provider timeout, 0 when a provider has none.
+func (a *app) runTimeout() time.Duration {
+	var timeout time.Duration
+	for _, s := range a.sources {
+		if s.job.Timeout <= 0 {
+			return 0
+		}
+		timeout = max(timeout, s.job.Timeout)
+	}
+	return timeout
+}
+
+// buildSubscriptions converts the saved searches and the targets of the watches into domain subscriptions, each one notified
+// through its channels, emailing its own recipients. providerNames maps the configured source keys to provider names.
+func buildSubscriptions(cfg config.AppConfig, logger *slog.Logger, providerNames map[string]string, emailOpts []email.Option) ([]core.Subscription, error) {
+	var subscriptions []core.Subscription
+	for _, sc := range cfg.AllSubscriptions() {
+		filter, err := buildFilter(sc.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter for subscription %s: %w", sc.Name, err)
+		}
+
+		var providers []string
+		for _, key := range sc.Providers {
+			name, ok := providerNames[key]
+			if !ok {
+				return nil, fmt.Errorf("invalid subscription %s: unknown or disabled provider %q", sc.Name, key)
+			}
+			providers = append(providers, name)
+		}
+
+		emailCfg := cfg.Email
+		if len(sc.EmailTo) > 0 {
+			emailCfg.To = sc.EmailTo
+		}
+		if sc.Quiet.Window != "" {
+			emailCfg.Quiet = sc.Quiet
+		}
+		notifiers := cfg.Watcher.Notifiers
+		if len(sc.Notifiers) > 0 {
+			notifiers = sc.Notifiers
+		}
+		channels, err := buildChannels(notifiers, notifierEnv{
+			logger:    logger.With("subscription", sc.Name),
+			email:     emailCfg,
+			emailOpts: emailOpts,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid subscription %s: %w", sc.Name, err)
+		}
+
+		subscriptions = append(subscriptions, core.Subscription{
+			Name:      sc.Name,
+			Providers: providers,
+			Filter:    filter,
+			Channels:  channels,
+		})
+	}
+	return subscriptions, nil
+}
+
+// buildQuietHours converts the quiet hours of a channel into a domain window.
+func buildQuietHours(cfg config.QuietHoursConfig) (core.QuietHours, error) {
+	quiet, err := core.ParseQuietHours(cfg.Window, cfg.Timezone)
+	if err != nil {
+		return core.QuietHours{}, err
+	}
+	quiet.AllowUrgent = cfg.AllowUrgent
+	return quiet, nil
+}
+
+// buildFilter converts the filter settings of a source into domain rules.
+func buildFilter(cfg config.FilterConfig) (core.Filter, error) {
+	filter := core.Filter{
+		MinPrice: cfg.MinPrice,
+		MaxPrice: cfg.MaxPrice,
+		Include:  cfg.Include,
+		Exclude:  cfg.Exclude,
+	}
+
+	if cfg.Pattern != "" {
+		pattern, err := regexp.Compile(cfg.Pattern)
+		if err != nil {
+			return core.Filter{}, fmt.Errorf("invalid pattern: %w", err)
+		}
+		filter.Pattern = pattern
+	}
+
+	var err error
+	if filter.Ranges, err = core.ParseRanges(cfg.Ranges); err != nil {
+		return core.Filter{}, err
+	}
+	if filter.Matches, err = core.ParseMatches(cfg.Matches); err != nil {
+		return core.Filter{}, err
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/maximerauch/go-classifieds-watcher/internal/config"
+	"github.com/maximerauch/go-classifieds-watcher/internal/core"
+)
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// defaultConfig returns the default configuration, provider sections following the watcher schedule as once loaded.
+func defaultConfig() config.AppConfig {
+	cfg := config.Defaults()
+	cfg.Asi67.Schedule = cfg.Watcher.Schedule
+	cfg.RememberMe.Schedule = cfg.Watcher.Schedule
+	return cfg
+}
+
+func TestNewApp(t *testing.T) {
+	tests := []struct {
+		name        string
+		configure   func(cfg *config.AppConfig)
+		wantSources []string // Provider names, in order
+		wantErr     string
+	}{
+		{
+			name:        "Provider sections keep their default name",
+			configure:   func(cfg *config.AppConfig) {},
+			wantSources: []string{"remember-me-france", "asi67 (api-client-v2)"},
+		},
+		{
+			name: "Watches are named after their provider name",
+			configure: func(cfg *config.AppConfig) {
+				cfg.Watcher.Providers = nil
+				cfg.Watches = []config.WatchConfig{
+					{Name: "flats", Type: "asi67", ProviderName: "flats", Schedule: cfg.Watcher.Schedule},
+					{Name: "dogs", Type: "rememberme", ProviderName: "dogs", Schedule: cfg.Watcher.Schedule},
+				}
+			},
+			wantSources: []string{"flats", "dogs"},
+		},
+		{
+			name: "Unknown provider type",
+			configure: func(cfg *config.AppConfig) {
+				cfg.Watcher.Providers = []string{"leboncoin"}
+			},
+			wantErr: `unknown provider "leboncoin"`,
+		},
+		{
+			name: "Two watches storing the same items",
+			configure: func(cfg *config.AppConfig) {
+				cfg.Watcher.Providers = nil
+				cfg.Watches = []config.WatchConfig{
+					{Name: "flats", Type: "asi67", ProviderName: "flats", Schedule: cfg.Watcher.Schedule},
+					{Name: "houses", Type: "asi67", ProviderName: "flats", Schedule: cfg.Watcher.Schedule},
+				}
+			},
+			wantErr: "items of flats are already stored by another source",
+		},
+		{
+			name: "Watch storing the items of a provider section",
+			configure: func(cfg *config.AppConfig) {
+				cfg.Watches = []config.WatchConfig{
+					{Name: "flats", Type: "asi67", ProviderName: "asi67 (api-client-v2)", Schedule: cfg.Watcher.Schedule},
+				}
+			},
+			wantErr: "already stored by another source",
+		},
+		{
+			name: "Invalid schedule",
+			configure: func(cfg *config.AppConfig) {
+				cfg.Asi67.Schedule.Spec = "every day"
+			},
+			wantErr: "invalid schedule for asi67 (api-client-v2)",
+		},
+		{
+			name: "No notifier",
+			configure: func(cfg *config.AppConfig) {
+				cfg.Watcher.Notifiers = nil
+			},
+			wantErr: "no notifier enabled",
+		},
+		{
+			name: "Unknown notifier",
+			configure: func(cfg *config.AppConfig) {
+				cfg.Watcher.Notifiers = []string{"sms"}
+			},
+			wantErr: `unknown notifier "sms"`,
+		},
+		{
+			name: "Unknown change policy",
+			configure: func(cfg *config.AppConfig) {
+				cfg.Watcher.ChangeNotifications = "sometimes"
+			},
+			wantErr: `unknown change policy "sometimes"`,
+		},
+		{
+			name: "Subscription to a disabled provider",
+			configure: func(cfg *config.AppConfig) {
+				cfg.Watcher.Providers = []string{"asi67"}
+				cfg.Subscriptions = []config.SubscriptionConfig{{Name: "dogs", Providers: []string{"rememberme"}}}
+			},
+			wantErr: `invalid subscription dogs: unknown or disabled provider "rememberme"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			tt.configure(&cfg)
+
+			a, err := newApp(cfg, discardLogger)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("newApp() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newApp() returned an unexpected error: %v", err)
+			}
+
+			var names []string
+			for _, s := range a.sources {
+				names = append(names, s.provider.Name())
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantSources, ",") {
+				t.Errorf("Sources = %v, want %v", names, tt.wantSources)
+			}
+			if len(a.channels) != 2 || a.channels[0].Name != "log" || a.channels[1].Name != "email" {
+				t.Errorf("Channels = %+v, want log and email", a.channels)
+			}
+		})
+	}
+}
+
+func TestBuildFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.FilterConfig
+		check   func(f core.Filter) bool
+		wantErr string
+	}{
+		{
+			name: "Empty settings match everything",
+			cfg:  config.FilterConfig{},
+			check: func(f core.Filter) bool {
+				return f.Pattern == nil && len(f.Ranges) == 0 && len(f.Matches) == 0
+			},
+		},
+		{
+			name: "Every rule is converted",
+			cfg: config.FilterConfig{
+				MinPrice: 500,
+				MaxPrice: 900,
+				Include:  []string{"balcon"},
+				Pattern:  `(?i)t[23]\b`,
+				Ranges:   "surface:40:,rooms:2:4",
+				Matches:  "city:Strasbourg|Schiltigheim",
+			},
+			check: func(f core.Filter) bool {
+				return f.MinPrice == 500 && f.MaxPrice == 900 && len(f.Include) == 1 && f.Pattern.MatchString("T2 lumineux") &&
+					len(f.Ranges) == 2 && f.Ranges[1] == (core.RangeRule{Field: "rooms", Min: 2, Max: 4}) &&
+					len(f.Matches) == 1 && len(f.Matches[0].Values) == 2
+			},
+		},
+		{
+			name:    "Invalid pattern",
+			cfg:     config.FilterConfig{Pattern: "(unclosed"},
+			wantErr: "invalid pattern",
+		},
+		{
+			name:    "Invalid range",
+			cfg:     config.FilterConfig{Ranges: "surface:big:"},
+			wantErr: `invalid range "surface:big:"`,
+		},
+		{
+			name:    "Unknown match field",
+			cfg:     config.FilterConfig{Matches: "colour:black"},
+			wantErr: `invalid match "colour:black": unknown field`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := buildFilter(tt.cfg)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("buildFilter() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildFilter() returned an unexpected error: %v", err)
+			}
+			if !tt.check(filter) {
+				t.Errorf("buildFilter() = %+v, want the configured rules", filter)
+			}
+		})
+	}
+}
+
+func TestBuildSubscriptions(t *testing.T) {
+	providerNames := map[string]string{"asi67": "asi67 (api-client-v2)", "dogs": "dogs"}
+
+	tests := []struct {
+		name      string
+		configure func(cfg *config.AppConfig)
+		want      []string // Subscriptions as "name:providers:channels"
+		wantErr   string
+	}{
+		{
+			name:      "No subscription",
+			configure: func(cfg *config.AppConfig) {},
+		},
+		{
+			name: "Saved searches watch provider names through their own channels",
+			configure: func(cfg *config.AppConfig) {
+				cfg.Subscriptions = []config.SubscriptionConfig{
+					{Name: "flats", Providers: []string{"asi67"}, Notifiers: []string{"email"}, EmailTo: []string{"bob@test.com"}},
+					{Name: "all", Filter: config.FilterConfig{MaxPrice: 800}},
+				}
+			},
+			want: []string{"flats:asi67 (api-client-v2):email", "all::log,email"},
+		},
+		{
+			name: "Watches notifying their own targets become subscriptions",
+			configure: func(cfg *config.AppConfig) {
+				cfg.Watches = []config.WatchConfig{
+					{Name: "dogs", Type: "rememberme", Notify: config.NotifyConfig{Notifiers: []string{"log"}}},
+				}
+			},
+			want: []string{"dogs:dogs:log"},
+		},
+		{
+			name: "Invalid filter",
+			configure: func(cfg *config.AppConfig) {
+				cfg.Subscriptions = []config.SubscriptionConfig{{Name: "flats", Filter: config.FilterConfig{Ranges: "size:1:2"}}}
+			},
+			wantErr: "invalid filter for subscription flats",
+		},
+		{
+			name: "Unknown notifier",
+			configure: func(cfg *config.AppConfig) {
+				cfg.Subscriptions = []config.SubscriptionConfig{{Name: "flats", Notifiers: []string{"sms"}}}
+			},
+			wantErr: `invalid subscription flats: unknown notifier "sms"`,
+		},
+		{
+			name: "Invalid quiet hours",
+			configure: func(cfg *config.AppConfig) {
+				cfg.Subscriptions = []config.SubscriptionConfig{{Name: "flats", Quiet: config.QuietHoursConfig{Window: "22h"}}}
+			},
+			wantErr: "invalid subscription flats: invalid notifier email: invalid quiet hours",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			tt.configure(&cfg)
+
+			subscriptions, err := buildSubscriptions(cfg, discardLogger, providerNames, nil)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("buildSubscriptions() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildSubscriptions() returned an unexpected error: %v", err)
+			}
+
+			var got []string
+			for _, sub := range subscriptions {
+				var channels []string
+				for _, ch := range sub.Channels {
+					channels = append(channels, ch.Name)
+				}
+				got = append(got, sub.Name+":"+strings.Join(sub.Providers, ",")+":"+strings.Join(channels, ","))
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("buildSubscriptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/maximerauch/go-classifieds-watcher/internal/adapters/email"
+	"github.com/maximerauch/go-classifieds-watcher/internal/adapters/recorder"
+	"github.com/maximerauch/go-classifieds-watcher/internal/config"
+	"github.com/maximerauch/go-classifieds-watcher/internal/core"
+	"github.com/maximerauch/go-classifieds-watcher/internal/scheduler"
+	"gopkg.in/yaml.v3"
+)
+
+// runDaemon scans each provider on startup, then on its own schedule, until the context is cancelled.
+func runDaemon(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	bootstrap := flags.Bool("bootstrap", false, "seed every provider without notifying its current listings")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	logger := newLogger(false)
+	a, svc, err := setup(logger, *bootstrap)
+	if err != nil {
+		return err
+	}
+
+	// Switched mode to "daemon-worker" to reflect the long-running nature
+	logger.Info("starting go-classifieds-watcher", "mode", "daemon-worker")
+
+	jobs := make([]scheduler.Job, len(a.sources))
+	for i, s := range a.sources {
+		name := s.provider.Name()
+		jobs[i] = s.job
+		jobs[i].Run = func(ctx context.Context) error {
+			report, err := svc.RunProvider(ctx, name)
+			logger.Info("scan finished", "provider", name, "duration", report.Duration, "degraded", report.Degraded())
+			return err
+		}
+	}
+
+	logger.Info("worker started", "providers", len(jobs))
+	scheduler.New(logger, jobs...).Run(ctx)
+
+	// The context was cancelled by a shutdown signal from Docker
+	logger.Info("shutdown signal received, worker stopped")
+	return nil
+}
+
+// runOnce scans every provider a single time, failing when a provider failed.
+func runOnce(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("run-once", flag.ContinueOnError)
+	bootstrap := flags.Bool("bootstrap", false, "seed every provider without notifying its current listings")
+	dryRun := flags.Bool("dry-run", false, "print what would be notified instead of saving and sending it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *dryRun {
+		return runDry(ctx, *bootstrap)
+	}
+
+	logger := newLogger(false)
+	a, svc, err := setup(logger, *bootstrap)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("starting go-classifieds-watcher", "mode", "run-once")
+
+	// The scan is bounded by the longest provider timeout
+	if timeout := a.runTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	report, err := svc.Run(ctx)
+	logger.Info("scan finished", "duration", report.Duration, "degraded", report.Degraded())
+	return err
+}
+
+// runDry runs the whole pipeline against recorders: nothing is read from or written to the database,
+// so every current item is handled as new. Rendered emails and the recorded notifications are printed.
+func runDry(ctx context.Context, bootstrap bool) error {
+	cfg, err := loadConfig(config.WithoutSecrets())
+	if err != nil {
+		return err
+	}
+	if bootstrap {
+		cfg.Watcher.Bootstrap = "force"
+	}
+
+	// Logs go to stderr, keeping stdout for the rendered emails
+	a, err := newApp(cfg, newLogger(true), email.WithOutput(os.Stdout))
+	if err != nil {
+		return err
+	}
+	channels := a.record()
+	repo := recorder.NewRepository()
+
+	if timeout := a.runTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	_, runErr := a.service(repo, nil).Run(ctx)
+
+	fmt.Println("DRY RUN: nothing was saved nor sent")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHANNEL\tKIND\tPROVIDER\tID\tTITLE")
+	notified := 0
+	for _, ch := range channels {
+		for _, n := range ch.notifier.Recorded() {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", ch.name, n.Kind, n.Item.Provider, n.Item.ID, n.Item.Title)
+			notified++
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("%d notifications, %d items would be saved\n", notified, len(repo.Saved()))
+
+	return runErr
+}
+
+// loadConfig reads and validates the configuration file named by CONFIG_FILE, if any, overridden by the environment.
+func loadConfig(opts ...config.Option) (config.AppConfig, error) {
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		return config.LoadFile(path, opts...)
+	}
+	return config.Load(opts...)
+}
+
+// setup loads the configuration and connects the service to the configured repository.
+func setup(logger *slog.Logger, bootstrap bool) (*app, *core.WatcherService, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	if bootstrap {
+		cfg.Watcher.Bootstrap = "force"
+	}
+
+	a, err := newApp(cfg, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	repo, outbox, err := a.openRepository()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return a, a.service(repo, outbox), nil
+}
+
+// runPreview prints the current items of a provider, without saving nor notifying anything.
+func runPreview(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("preview", flag.ContinueOnError)
+	format := flags.String("format", "table", "output format: table or json")
+	filtered := flags.Bool("filter", false, "only print the items accepted by the provider filter")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("want a single provider, e.g. preview asi67")
+	}
+	if *format != "table" && *format != "json" {
+		return fmt.Errorf("unknown format %q, want table or json", *format)
+	}
+
+	cfg, err := loadConfig(config.WithoutSecrets())
+	if err != nil {
+		return err
+	}
+	a, err := newApp(cfg, newLogger(true))
+	if err != nil {
+		return err
+	}
+	s, ok := a.source(flags.Arg(0))
+	if !ok {
+		return fmt.Errorf("unknown provider %q", flags.Arg(0))
+	}
+
+	if s.job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.job.Timeout)
+		defer cancel()
+	}
+
+	items, err := core.Collect(core.Stream(s.provider).StreamItems(ctx))
+	if err != nil {
+		var partial *core.PartialFetchError
+		if !errors.As(err, &partial) {
+			return err
+		}
+		// Best effort: print what we got
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+
+	if *filtered {
+		var accepted []core.Item
+		for _, item := range items {
+			if ok, _ := s.filter.Match(item); ok {
+				accepted = append(accepted, item)
+			}
+		}
+		items = accepted
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(items)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tPRICE\tTITLE\tURL")
+	for _, item := range items {
+		price := "-"
+		if item.Price > 0 {
+			price = fmt.Sprintf("%.2f %s", item.Price, item.Currency)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", item.ID, price, item.Title, item.Url)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d items\n", len(items))
+	return nil
+}
+
+// runTestNotify sends a sample item through every configured channel, reporting each outcome.
+func runTestNotify(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments %v", args)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	a, err := newApp(cfg, newLogger(true))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	item := core.Item{
+		ID:          fmt.Sprintf("test-%d", now.Unix()),
+		Provider:    "test-notify",
+		Title:       "Test notification",
+		Description: "Sample item sent by the test-notify command: the notifier works.",
+		Price:       42,
+		Currency:    "EUR",
+		Url:         "https://github.com/maximerauch/go-classifieds-watcher",
+		PublishedAt: now,
+	}
+
+	var errs []error
+	for _, ch := range a.allChannels() {
+		if err := ch.Notifier.Send(ctx, item); err != nil {
+			fmt.Printf("✗ %s: %v\n", ch.Name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", ch.Name, err))
+			continue
+		}
+		fmt.Printf("✓ %s\n", ch.Name)
+	}
+	return errors.Join(errs...)
+}
+
+// runValidateConfig checks that the configuration can be used, without connecting to anything.
+func runValidateConfig(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("validate-config", flag.ContinueOnError)
+	printConfig := flags.Bool("print", false, "print the effective configuration, secrets redacted")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %v", flags.Args())
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	a, err := newApp(cfg, newLogger(true))
+	if err != nil {
+		return err
+	}
+
+	if *printConfig {
+		out, err := yaml.Marshal(cfg.Redacted())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", out)
+	}
+	for _, s := range a.sources {
+		fmt.Printf("provider %s (%s): schedule %q, next scheduled run at %s\n", s.key, s.provider.Name(), s.schedule, s.job.Schedule.Next(time.Now()).Format(time.RFC3339))
+	}
+	for _, sub := range a.subscriptions {
+		fmt.Printf("subscription %s: %d channels\n", sub.Name, len(sub.Channels))
+	}
+	fmt.Println("configuration is valid")
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// captureStdout redirects the standard output to a file for the duration of the test, returning a function reading it.
+func captureStdout(t *testing.T) func() string {
+	t.Helper()
+	file, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = file
+	t.Cleanup(func() {
+		os.Stdout = stdout
+		_ = file.Close()
+	})
+	return func() string {
+		data, err := os.ReadFile(file.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+}
+
+func TestRunDry(t *testing.T) {
+	// The shelter lists a single dog, its detail page being the same document
+	shelter := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><body>
+<article id="pet-42" class="pets">
+  <header class="pet-header"><a href="http://%s/pets/rex/"><h3 class="pet-title">Rex</h3></a></header>
+  <section class="pet-content">Chien mâle de 2 ans</section>
+</article>
+</body></html>`, r.Host)
+	}))
+	defer shelter.Close()
+
+	// Any connection to the SMTP server would be a sent email
+	smtp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var connections atomic.Int32
+	go func() {
+		for {
+			conn, err := smtp.Accept()
+			if err != nil {
+				return
+			}
+			connections.Add(1)
+			_ = conn.Close()
+		}
+	}()
+	defer smtp.Close()
+
+	dir := t.TempDir()
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("WATCHER_PROVIDERS", "rememberme")
+	t.Setenv("WATCHER_NOTIFIERS", "log,email")
+	t.Setenv("WATCHER_BOOTSTRAP", "off")
+	t.Setenv("REMEMBERME_SEARCH_URL", shelter.URL+"/pets/")
+	t.Setenv("REPOSITORY_BACKEND", "json")
+	t.Setenv("REPOSITORY_DATA_FILE_PATH", filepath.Join(dir, "data", "seen.json"))
+	t.Setenv("REMEMBERME_DATA_FILE_PATH", filepath.Join(dir, "data", "rememberme-seen.json"))
+	t.Setenv("OUTBOX_DATA_FILE_PATH", filepath.Join(dir, "data", "outbox.json"))
+	t.Setenv("SMTP_HOST", "127.0.0.1")
+	t.Setenv("SMTP_PORT", fmt.Sprint(smtp.Addr().(*net.TCPAddr).Port))
+	t.Setenv("EMAIL_FROM", "bot@test.com")
+	t.Setenv("EMAIL_TO", "user@test.com")
+
+	output := captureStdout(t)
+	if err := runDry(context.Background(), false); err != nil {
+		t.Fatalf("runDry() returned an unexpected error: %v", err)
+	}
+
+	out := output()
+	for _, want := range []string{"DRY RUN", "Rex", "2 notifications, 1 items would be saved"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("runDry() output = %s; want it to contain %q", out, want)
+		}
+	}
+
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 0 {
+		t.Errorf("runDry() wrote %v to the json repository directory, want nothing", entries)
+	}
+	if n := connections.Load(); n != 0 {
+		t.Errorf("runDry() connected %d times to the SMTP server, want none", n)
+	}
+}
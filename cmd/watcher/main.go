@@ -2,95 +2,69 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
-	"time"
-
-	"github.com/maximerauch/go-classifieds-watcher/internal/adapters/composite"
-	"github.com/maximerauch/go-classifieds-watcher/internal/adapters/email"
-	"github.com/maximerauch/go-classifieds-watcher/internal/adapters/postgres"
-	"github.com/maximerauch/go-classifieds-watcher/internal/adapters/rememberme"
-	"github.com/maximerauch/go-classifieds-watcher/internal/adapters/std"
-	"github.com/maximerauch/go-classifieds-watcher/internal/config"
-	"github.com/maximerauch/go-classifieds-watcher/internal/core"
+	_ "time/tzdata" // Quiet hours timezones, for images without a zoneinfo database
 )
 
-func main() {
-	// Initialize JSON logger for structured logging (Cloud-Native standard)
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+// command is a subcommand of the watcher binary.
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
 
+var commands = []command{
+	{"daemon", "daemon [-bootstrap]", "scan every provider on its schedule until stopped (default)", runDaemon},
+	{"run-once", "run-once [-bootstrap] [-dry-run]", "scan every provider once, for cron or CI use", runOnce},
+	{"preview", "preview [-format table|json] [-filter] <provider>", "print the current items of a provider without saving or notifying them", runPreview},
+	{"test-notify", "test-notify", "send a sample item through every configured notifier", runTestNotify},
+	{"validate-config", "validate-config [-print]", "check the configuration without connecting to anything", runValidateConfig},
+}
+
+func main() {
 	// Create a context that cancels on SIGINT (Ctrl+C) or SIGTERM (Docker/PaaS stop signal)
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
-	if err := run(ctx, logger); err != nil {
-		logger.Error("fatal application error", "error", err)
-		os.Exit(1)
+	// Without subcommand, the binary runs the daemon as it always did
+	name, args := "daemon", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
 
-	os.Exit(0)
-}
-
-func run(ctx context.Context, logger *slog.Logger) error {
-	// Load Configuration
-	cfg := config.Load()
-
-	// Switched mode to "daemon-worker" to reflect the long-running nature
-	logger.Info("starting go-classifieds-watcher", "mode", "daemon-worker")
-
-	// Initialize PostgreSQL repository
-	repo, err := postgres.NewRepository(cfg.Database.DSN)
-	if err != nil {
-		return err
+	for _, cmd := range commands {
+		if cmd.name == name {
+			if err := cmd.run(ctx, args); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
 	}
 
-	// Setup Notifiers: Combine Logger (stdout) and Email
-	notifier := composite.NewCompositeNotifier(
-		std.NewLoggerNotifier(logger),
-		email.NewEmailNotifier(cfg.Email),
-	)
-
-	// Setup Provider
-	provider := rememberme.NewProvider(cfg.RememberMe.SearchURL)
-
-	// Initialize the Domain Service
-	svc := core.NewWatcherService(provider, repo, notifier, logger)
+	usage()
+	os.Exit(2)
+}
 
-	// Step A: Immediate execution on startup (Fail-safe check)
-	logger.Info("executing initial scan")
-	if err := svc.Run(ctx); err != nil {
-		// In daemon mode, we log errors but do not crash the app unless it's critical.
-		logger.Error("initial scan failed", "error", err)
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: watcher <command> [arguments]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-52s %s\n", cmd.usage, cmd.summary)
 	}
+}
 
-	// Step B: Scheduled execution (every 15 minutes)
-	ticker := time.NewTicker(15 * time.Minute)
-	defer ticker.Stop()
-
-	logger.Info("worker started, waiting for next cycle", "interval", "15m")
-
-	for {
-		select {
-		case <-ctx.Done():
-			// Handle graceful shutdown signal from Docker
-			logger.Info("shutdown signal received, stopping worker")
-			return nil
-
-		case <-ticker.C:
-			// The timer triggered
-			logger.Info("starting scheduled scan")
-
-			// Create a timeout context for this specific job execution (2 minutes max)
-			// This prevents a stuck network call from hanging the worker forever.
-			jobCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
-
-			if err := svc.Run(jobCtx); err != nil {
-				logger.Error("scheduled scan failed", "error", err)
-			}
-
-			cancel() // Always release context resources
-		}
+// newLogger creates the JSON logger for structured logging (Cloud-Native standard).
+// Interactive commands log to stderr, keeping stdout for their output.
+func newLogger(interactive bool) *slog.Logger {
+	if interactive {
+		return slog.New(slog.NewJSONHandler(os.Stderr, nil))
 	}
+	return slog.New(slog.NewJSONHandler(os.Stdout, nil))
 }
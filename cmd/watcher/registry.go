@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/maximerauch/go-classifieds-watcher/internal/adapters/asi67"
+	"github.com/maximerauch/go-classifieds-watcher/internal/adapters/email"
+	"github.com/maximerauch/go-classifieds-watcher/internal/adapters/fs"
+	"github.com/maximerauch/go-classifieds-watcher/internal/adapters/postgres"
+	"github.com/maximerauch/go-classifieds-watcher/internal/adapters/rememberme"
+	"github.com/maximerauch/go-classifieds-watcher/internal/adapters/std"
+	"github.com/maximerauch/go-classifieds-watcher/internal/config"
+	"github.com/maximerauch/go-classifieds-watcher/internal/core"
+)
+
+// providerFactories builds the sources of each provider type, named after their watch unless it keeps the default name.
+var providerFactories = map[string]func(w config.WatchConfig, logger *slog.Logger) core.Provider{
+	"rememberme": func(w config.WatchConfig, logger *slog.Logger) core.Provider {
+		opts := []rememberme.Option{rememberme.WithLogger(logger)}
+		if w.ProviderName != "" {
+			opts = append(opts, rememberme.WithName(w.ProviderName))
+		}
+		return rememberme.NewProvider(w.Search.URL, opts...)
+	},
+	"asi67": func(w config.WatchConfig, logger *slog.Logger) core.Provider {
+		criteria := w.Search.Asi67SearchConfig
+		opts := []asi67.Option{asi67.WithSearch(asi67.Search{
+			Offer:        asi67.Offer(criteria.Offer),
+			PropertyType: criteria.PropertyType,
+			Location:     criteria.Location,
+			RadiusKm:     criteria.RadiusKm,
+			BudgetMin:    criteria.BudgetMin,
+			BudgetMax:    criteria.BudgetMax,
+			SurfaceMin:   criteria.SurfaceMin,
+			SurfaceMax:   criteria.SurfaceMax,
+			RoomsMin:     criteria.RoomsMin,
+			RoomsMax:     criteria.RoomsMax,
+		}), asi67.WithLogger(logger)}
+		if w.ProviderName != "" {
+			opts = append(opts, asi67.WithName(w.ProviderName))
+		}
+		return asi67.NewProvider(w.Search.URL, w.Search.ItemsPerPage, opts...)
+	},
+}
+
+// repositoryFactories opens the storage backends that can be selected by REPOSITORY_BACKEND.
+// dataFiles holds the JSON file of each enabled provider, keyed by provider name.
+var repositoryFactories = map[string]func(cfg config.AppConfig, dataFiles map[string]string) (core.Repository, core.Outbox, error){
+	"postgres": func(cfg config.AppConfig, dataFiles map[string]string) (core.Repository, core.Outbox, error) {
+		repo, err := postgres.NewRepository(cfg.Database.DSN)
+		if err != nil {
+			return nil, nil, err
+		}
+		return repo, postgres.NewOutbox(repo), nil
+	},
+	"json": func(cfg config.AppConfig, dataFiles map[string]string) (core.Repository, core.Outbox, error) {
+		// Files are created on first save, their directories must exist
+		paths := append(slices.Collect(maps.Values(dataFiles)), cfg.Repository.DataFilePath, cfg.Outbox.DataFilePath)
+		for _, path := range paths {
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return nil, nil, fmt.Errorf("failed to create data directory: %w", err)
+			}
+		}
+		return fs.NewShardedRepository(dataFiles, cfg.Repository.DataFilePath), fs.NewJSONOutbox(cfg.Outbox.DataFilePath), nil
+	},
+}
+
+// notifierEnv is what notifier factories build a channel from.
+type notifierEnv struct {
+	logger    *slog.Logger
+	email     config.EmailConfig // Email settings of the subscription, or the global ones
+	emailOpts []email.Option
+}
+
+// notifierFactories builds the channels that can be enabled by WATCHER_NOTIFIERS, named after their key.
+var notifierFactories = map[string]func(env notifierEnv) (core.Channel, error){
+	"log": func(env notifierEnv) (core.Channel, error) {
+		return core.Channel{Notifier: std.NewLoggerNotifier(env.logger)}, nil
+	},
+	"email": func(env notifierEnv) (core.Channel, error) {
+		quiet, err := buildQuietHours(env.email.Quiet)
+		if err != nil {
+			return core.Channel{}, err
+		}
+		return core.Channel{Notifier: email.NewEmailNotifier(env.email, env.emailOpts...), Quiet: quiet}, nil
+	},
+}
+
+// buildChannels creates the enabled notification channels.
+func buildChannels(names []string, env notifierEnv) ([]core.Channel, error) {
+	var channels []core.Channel
+	for _, name := range names {
+		factory, ok := notifierFactories[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown notifier %q, want one of %v", name, slices.Sorted(maps.Keys(notifierFactories)))
+		}
+		ch, err := factory(env)
+		if err != nil {
+			return nil, fmt.Errorf("invalid notifier %s: %w", name, err)
+		}
+		ch.Name = name
+		channels = append(channels, ch)
+	}
+	return channels, nil
+}
@@ -5,12 +5,18 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
+	"log/slog"
 	"math"
 	"net/http"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/PuerkitoBio/goquery"
 	"github.com/maximerauch/go-classifieds-watcher/internal/core"
 )
 
@@ -33,76 +39,246 @@ type ItemDetail struct {
 	} `json:"title"`
 }
 
+// Offer is the kind of transaction of a search.
+type Offer string
+
+const (
+	OfferRent Offer = "rent"
+	OfferSale Offer = "sale"
+)
+
+// Search holds the criteria of an asi67 search. Zero criteria are disabled.
+// The criteria the search form is known to send are part of the request, the provider applies the others
+// to the listings it receives (see Search.accepts).
+type Search struct {
+	Offer        Offer
+	PropertyType string // e.g. "appt" or "maison"
+	Location     string // "<city>/<postal code>", e.g. "strasbourg/67000"
+	RadiusKm     int
+	BudgetMin    float64 // Monthly rent charges included, or sale price
+	BudgetMax    float64
+	SurfaceMin   float64 // Square meters
+	SurfaceMax   float64
+	RoomsMin     int
+	RoomsMax     int
+}
+
+// DefaultSearch looks for flats to rent around Strasbourg, up to 1000 EUR a month.
+var DefaultSearch = Search{
+	Offer:        OfferRent,
+	PropertyType: "appt",
+	Location:     "strasbourg/67000",
+	RadiusKm:     20,
+	BudgetMax:    1000,
+}
+
+// params returns the request parameters of a page of the search, as sent by the asi67 search form.
+// Only the maximum rent is sent among the ranges, the form having no other known parameter.
+func (s Search) params(page int) map[string]interface{} {
+	offer := "2"
+	if s.Offer == OfferSale {
+		offer = "1"
+	}
+
+	query := map[string]interface{}{
+		"page": strconv.Itoa(page),
+	}
+	set := func(key string, value string) {
+		if value != "" && value != "0" {
+			query[key] = value
+		}
+	}
+	set("prod.prod_type", s.PropertyType)
+	set("prod.geo", s.Location)
+	set("prod.geo_radius", strconv.Itoa(s.RadiusKm))
+	if s.Offer != OfferSale {
+		set("prod.budget_rent_max", formatNumber(s.BudgetMax))
+	}
+
+	params := map[string]interface{}{
+		"type_offer": offer,
+		"query":      query,
+	}
+	if s.PropertyType != "" {
+		params["prod_type"] = s.PropertyType
+	}
+	if s.Location != "" {
+		params["geo"] = s.Location
+	}
+	return params
+}
+
+// accepts reports whether a listing meets the criteria left out of the request.
+// Attributes a listing does not publish are not held against it.
+func (s Search) accepts(item core.Item) bool {
+	if s.BudgetMin > 0 && item.Price > 0 && item.Price < s.BudgetMin {
+		return false
+	}
+	// The maximum rent is applied by the API
+	if s.Offer == OfferSale && s.BudgetMax > 0 && item.Price > s.BudgetMax {
+		return false
+	}
+	if item.RealEstate == nil {
+		return true
+	}
+	return within(item.RealEstate.Surface, s.SurfaceMin, s.SurfaceMax) &&
+		within(float64(item.RealEstate.Rooms), float64(s.RoomsMin), float64(s.RoomsMax))
+}
+
+// within reports whether value lies between minimum and maximum, a zero value or bound being disabled.
+func within(value, minimum, maximum float64) bool {
+	if value == 0 {
+		return true
+	}
+	return (minimum == 0 || value >= minimum) && (maximum == 0 || value <= maximum)
+}
+
+// listingURL returns the page of a listing of the search.
+func (s Search) listingURL(id string) string {
+	if s.Offer == OfferSale {
+		return fmt.Sprintf("https://www.asi67.com/vente/vente,%s", id)
+	}
+	return fmt.Sprintf("https://www.asi67.com/location/location,%s", id)
+}
+
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// DefaultItemsPerPage is the page size of the asi67 search form, used when none is configured.
+const DefaultItemsPerPage = 12
+
 type Provider struct {
+	name         string
 	apiURL       string
 	itemsPerPage int // Dynamic config
+	search       Search
 	client       *http.Client
+	logger       *slog.Logger
 }
 
-func NewProvider(apiURL string, itemsPerPage int) *Provider {
-	return &Provider{
-		apiURL:       apiURL,
-		itemsPerPage: itemsPerPage,
-		client:       &http.Client{Timeout: 15 * time.Second},
+// Option customizes a Provider.
+type Option func(*Provider)
+
+// WithName names the provider, so that several searches keep their items apart.
+func WithName(name string) Option {
+	return func(p *Provider) {
+		p.name = name
 	}
 }
 
-func (p *Provider) Name() string {
-	return "asi67 (api-client-v2)"
+// WithLogger reports the incidents that do not fail a fetch, discarded otherwise.
+func WithLogger(logger *slog.Logger) Option {
+	return func(p *Provider) {
+		p.logger = logger
+	}
 }
 
-func (p *Provider) FetchItems(ctx context.Context) ([]core.Item, error) {
-	// Step 1: Fetch the first page synchronously to discover the total count
-	firstPageItems, totalCount, err := p.fetchPage(ctx, 1)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch page 1: %w", err)
+// WithSearch sets the criteria of the search, DefaultSearch otherwise.
+func WithSearch(search Search) Option {
+	return func(p *Provider) {
+		p.search = search
 	}
+}
 
-	// If no items or only one page, return immediately
-	if totalCount <= p.itemsPerPage {
-		return firstPageItems, nil
+// NewProvider creates a provider of the API at apiURL, reading itemsPerPage items per page.
+// A page size below 1 falls back to DefaultItemsPerPage.
+func NewProvider(apiURL string, itemsPerPage int, opts ...Option) *Provider {
+	if itemsPerPage < 1 {
+		itemsPerPage = DefaultItemsPerPage
 	}
+	p := &Provider{
+		name:         "asi67 (api-client-v2)",
+		apiURL:       apiURL,
+		itemsPerPage: itemsPerPage,
+		search:       DefaultSearch,
+		client:       &http.Client{Timeout: 15 * time.Second},
+		logger:       slog.New(slog.DiscardHandler),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	p.logger = p.logger.With("provider", p.name)
+	return p
+}
 
-	// Step 2: Calculate total pages needed
-	totalPages := int(math.Ceil(float64(totalCount) / float64(p.itemsPerPage)))
-	fmt.Printf("DEBUG: Found %d total items (%d pages). Starting parallel fetch...\n", totalCount, totalPages)
+func (p *Provider) Name() string {
+	return p.name
+}
 
-	// Step 3: Fan-out - Fetch remaining pages in parallel
-	var (
-		wg       sync.WaitGroup
-		mu       sync.Mutex
-		allItems = firstPageItems // Start with page 1 results
-	)
+// FetchItems collects the stream of items, for callers that need them all at once.
+func (p *Provider) FetchItems(ctx context.Context) ([]core.Item, error) {
+	return core.Collect(p.StreamItems(ctx))
+}
 
-	// Loop from page 2 to totalPages
-	for page := 2; page <= totalPages; page++ {
-		wg.Add(1)
-		go func(pNum int) {
-			defer wg.Done()
+// pageResult carries the outcome of a page fetched in the background.
+type pageResult struct {
+	page  int
+	items []core.Item
+	err   error
+}
 
-			// Check context cancellation before making request
-			if ctx.Err() != nil {
+// StreamItems yields the items page by page, as soon as each page is downloaded.
+func (p *Provider) StreamItems(ctx context.Context) iter.Seq2[core.Item, error] {
+	return func(yield func(core.Item, error) bool) {
+		// Step 1: Fetch the first page synchronously to discover the total count
+		firstPageItems, totalCount, err := p.fetchPage(ctx, 1)
+		if err != nil {
+			yield(core.Item{}, fmt.Errorf("failed to fetch page 1: %w", err))
+			return
+		}
+		for _, item := range firstPageItems {
+			if !yield(item, nil) {
 				return
 			}
+		}
 
-			items, _, err := p.fetchPage(ctx, pNum)
-			if err != nil {
-				// We log the error but don't stop the whole process (the best effort)
-				fmt.Printf("⚠️ Error fetching page %d: %v\n", pNum, err)
-				return
-			}
+		// If no items or only one page, we are done
+		if totalCount <= p.itemsPerPage {
+			return
+		}
 
-			// Thread-safe append
-			mu.Lock()
-			allItems = append(allItems, items...)
-			mu.Unlock()
-		}(page)
-	}
+		// Step 2: Calculate total pages needed
+		totalPages := int(math.Ceil(float64(totalCount) / float64(p.itemsPerPage)))
+		p.logger.Debug("starting parallel fetch", "items", totalCount, "pages", totalPages)
 
-	wg.Wait()
+		// Stop the remaining downloads if the consumer stops early
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
 
-	fmt.Printf("DEBUG: Successfully fetched %d items from %d pages.\n", len(allItems), totalPages)
-	return allItems, nil
+		// Step 3: Fan-out - Fetch remaining pages in parallel.
+		// The buffer lets every fetch complete, so no page can be silently dropped.
+		results := make(chan pageResult, totalPages-1)
+		var wg sync.WaitGroup
+		for page := 2; page <= totalPages; page++ {
+			wg.Add(1)
+			go func(pNum int) {
+				defer wg.Done()
+				items, _, err := p.fetchPage(ctx, pNum)
+				results <- pageResult{page: pNum, items: items, err: err}
+			}(page)
+		}
+		go func() {
+			wg.Wait()
+			close(results)
+		}()
+
+		// Step 4: Fan-in - Yield pages in completion order
+		for result := range results {
+			if result.err != nil {
+				// Best effort: keep the other pages, but report the scan as incomplete
+				if !yield(core.Item{}, core.PageError{Page: result.page, Err: result.err}) {
+					return
+				}
+				continue
+			}
+			for _, item := range result.items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
 }
 
 // fetchPage handles the API call for a specific page number.
@@ -110,18 +286,7 @@ func (p *Provider) FetchItems(ctx context.Context) ([]core.Item, error) {
 func (p *Provider) fetchPage(ctx context.Context, pageNum int) ([]core.Item, int, error) {
 	// Prepare Request Payload with the specific page number
 	requestBody := map[string]interface{}{
-		"params": map[string]interface{}{
-			"type_offer": "2",
-			"prod_type":  "appt",
-			"geo":        "strasbourg/67000",
-			"query": map[string]interface{}{
-				"page":                 strconv.Itoa(pageNum), // Inject page number here
-				"prod.prod_type":       "appt",
-				"prod.geo":             "strasbourg/67000",
-				"prod.geo_radius":      "20",
-				"prod.budget_rent_max": "1000",
-			},
-		},
+		"params": p.search.params(pageNum),
 	}
 
 	jsonPayload, err := json.Marshal(requestBody)
@@ -147,7 +312,7 @@ func (p *Provider) fetchPage(ctx context.Context, pageNum int) ([]core.Item, int
 
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
-			fmt.Printf("⚠️ Error closing response body: %v\n", closeErr)
+			p.logger.Warn("failed to close response body", "error", closeErr)
 		}
 	}()
 
@@ -164,7 +329,7 @@ func (p *Provider) fetchPage(ctx context.Context, pageNum int) ([]core.Item, int
 	// Map to Domain
 	var items []core.Item
 	for id, detail := range apiResp.Data.ProdID {
-		fullURL := fmt.Sprintf("https://www.asi67.com/location/location,%s", id)
+		fullURL := p.search.listingURL(id)
 
 		title := detail.Title.Fr
 		if title == "" {
@@ -176,16 +341,123 @@ func (p *Provider) fetchPage(ctx context.Context, pageNum int) ([]core.Item, int
 			price = detail.PricePrimary
 		}
 
-		items = append(items, core.Item{
-			ID:          id,
-			Title:       title,
-			Url:         fullURL,
-			Price:       price,
-			Currency:    "EUR",
+		// The primary price excludes charges when a total is published
+		rent, charges := price, 0.0
+		if detail.PricePrimary > 0 && detail.RentTotal > detail.PricePrimary {
+			rent, charges = detail.PricePrimary, detail.RentTotal-detail.PricePrimary
+		}
+		if p.search.Offer == OfferSale {
+			rent, charges = 0, 0
+		}
+
+		item := core.Item{
+			ID:       id,
+			Title:    title,
+			Url:      fullURL,
+			Price:    price,
+			Currency: "EUR",
+			// Kept as is for display: stored snapshots are compared against it
 			Description: fmt.Sprintf("%.0f m² - %s", detail.Surface, detail.City),
 			PublishedAt: time.Now(),
-		})
+			RealEstate: &core.RealEstateAttributes{
+				Surface:    detail.Surface,
+				Rooms:      parseRooms(title),
+				City:       detail.City,
+				PostalCode: detail.Cp,
+				Rent:       rent,
+				Charges:    charges,
+				Furnished:  parseFurnished(title),
+			},
+		}
+		if p.search.accepts(item) {
+			items = append(items, item)
+		}
 	}
 
 	return items, apiResp.Data.ProdCount, nil
 }
+
+// roomsPattern matches the usual room counts of French listings: "T2", "F3" or "2 pièces".
+var roomsPattern = regexp.MustCompile(`(?i)\b(?:[TF](\d+)|(\d+)\s*pi[eè]ces?)\b`)
+
+// parseRooms reads the number of rooms from a listing title, 0 when it is not mentioned.
+// A studio counts as a single room.
+func parseRooms(title string) int {
+	if match := roomsPattern.FindStringSubmatch(title); match != nil {
+		digits := match[1] + match[2]
+		if rooms, err := strconv.Atoi(digits); err == nil {
+			return rooms
+		}
+	}
+	if strings.Contains(strings.ToLower(title), "studio") {
+		return 1
+	}
+	return 0
+}
+
+// parseFurnished reports whether a listing title announces a furnished flat.
+func parseFurnished(title string) bool {
+	lower := strings.ToLower(title)
+	return strings.Contains(lower, "meublé") && !strings.Contains(lower, "non meublé")
+}
+
+// Enrich completes a listing from its detail page, as the list endpoint omits photos
+// and full descriptions.
+func (p *Provider) Enrich(ctx context.Context, item core.Item) (core.Item, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", item.Url, nil)
+	if err != nil {
+		return item, fmt.Errorf("create request error: %w", err)
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; ClassifiedsWatcher/1.0)")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return item, fmt.Errorf("http call error: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			p.logger.Warn("failed to close response body", "error", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return item, fmt.Errorf("detail page status %d", resp.StatusCode)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return item, fmt.Errorf("html parse error: %w", err)
+	}
+	return enrichFromDocument(item, doc), nil
+}
+
+// enrichFromDocument reads the details of a listing from the OpenGraph tags of its detail page.
+func enrichFromDocument(item core.Item, doc *goquery.Document) core.Item {
+	if description := doc.Find(`meta[property="og:description"]`).AttrOr("content", ""); description != "" {
+		item.FullDescription = strings.TrimSpace(description)
+	}
+
+	doc.Find(`meta[property="og:image"]`).Each(func(i int, s *goquery.Selection) {
+		if src := s.AttrOr("content", ""); src != "" && !slices.Contains(item.Images, src) {
+			item.Images = append(item.Images, src)
+		}
+	})
+
+	if published, ok := doc.Find(`meta[property="article:published_time"]`).Attr("content"); ok {
+		if at, err := time.Parse(time.RFC3339, published); err == nil {
+			item.PublishedAt = at
+		}
+	}
+
+	// The full description often tells what the title does not
+	if item.RealEstate != nil && item.FullDescription != "" {
+		attrs := *item.RealEstate
+		if attrs.Rooms == 0 {
+			attrs.Rooms = parseRooms(item.FullDescription)
+		}
+		attrs.Furnished = attrs.Furnished || parseFurnished(item.FullDescription)
+		item.RealEstate = &attrs
+	}
+
+	return item
+}
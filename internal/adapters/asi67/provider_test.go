@@ -0,0 +1,193 @@
+package asi67
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/maximerauch/go-classifieds-watcher/internal/core"
+)
+
+func TestParseRooms(t *testing.T) {
+	tests := []struct {
+		title string
+		want  int
+	}{
+		{"Appartement T2 Schiltigheim", 2},
+		{"F3 lumineux avec balcon", 3},
+		{"Bel appartement 4 pièces", 4},
+		{"Studio meublé centre-ville", 1},
+		{"Apartment in Strasbourg (67000)", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			if got := parseRooms(tt.title); got != tt.want {
+				t.Errorf("parseRooms(%q) = %d, want %d", tt.title, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFurnished(t *testing.T) {
+	tests := map[string]bool{
+		"Studio meublé centre-ville":  true,
+		"T2 non meublé":               false,
+		"Appartement T2 Schiltigheim": false,
+	}
+
+	for title, want := range tests {
+		if got := parseFurnished(title); got != want {
+			t.Errorf("parseFurnished(%q) = %v, want %v", title, got, want)
+		}
+	}
+}
+
+func TestEnrichFromDocument(t *testing.T) {
+	page := `<html><head>
+		<meta property="og:description" content=" Bel appartement 3 pièces meublé, proche tram. ">
+		<meta property="og:image" content="https://asi67.com/photo-1.jpg">
+		<meta property="og:image" content="https://asi67.com/photo-2.jpg">
+		<meta property="og:image" content="https://asi67.com/photo-1.jpg">
+	</head><body></body></html>`
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("Failed to parse page: %v", err)
+	}
+
+	listed := core.Item{ID: "42", Title: "Appartement Strasbourg", RealEstate: &core.RealEstateAttributes{Surface: 60}}
+	item := enrichFromDocument(listed, doc)
+
+	if item.FullDescription != "Bel appartement 3 pièces meublé, proche tram." {
+		t.Errorf("FullDescription = %q", item.FullDescription)
+	}
+	if len(item.Images) != 2 {
+		t.Errorf("Images = %v, want 2 distinct photos", item.Images)
+	}
+	if item.RealEstate.Rooms != 3 || !item.RealEstate.Furnished || item.RealEstate.Surface != 60 {
+		t.Errorf("RealEstate = %+v, want the listed attributes completed by the description", item.RealEstate)
+	}
+	if listed.RealEstate.Rooms != 0 {
+		t.Error("Enrichment should not alter the listed item")
+	}
+}
+
+func TestSearchParams(t *testing.T) {
+	// The default search must keep sending the request of the original hardcoded search
+	t.Run("Default search", func(t *testing.T) {
+		params := DefaultSearch.params(3)
+		query := params["query"].(map[string]interface{})
+
+		if params["type_offer"] != "2" || params["prod_type"] != "appt" || params["geo"] != "strasbourg/67000" {
+			t.Errorf("params = %v, want a flat rental around Strasbourg", params)
+		}
+		want := map[string]string{
+			"page":                 "3",
+			"prod.prod_type":       "appt",
+			"prod.geo":             "strasbourg/67000",
+			"prod.geo_radius":      "20",
+			"prod.budget_rent_max": "1000",
+		}
+		if len(query) != len(want) {
+			t.Errorf("query = %v, want %v", query, want)
+		}
+		for key, value := range want {
+			if query[key] != value {
+				t.Errorf("query[%s] = %v, want %s", key, query[key], value)
+			}
+		}
+	})
+
+	t.Run("Sale search", func(t *testing.T) {
+		search := Search{Offer: OfferSale, PropertyType: "maison", BudgetMin: 150000, BudgetMax: 320000.5, SurfaceMin: 90, RoomsMin: 4}
+		params := search.params(1)
+		query := params["query"].(map[string]interface{})
+
+		if params["type_offer"] != "1" {
+			t.Errorf("type_offer = %v, want 1 for a sale", params["type_offer"])
+		}
+		if _, ok := params["geo"]; ok {
+			t.Error("geo should be left out without location")
+		}
+		// Only the criteria of the search form are sent, the others are applied to the listings
+		want := map[string]string{"page": "1", "prod.prod_type": "maison"}
+		if len(query) != len(want) || query["page"] != "1" || query["prod.prod_type"] != "maison" {
+			t.Errorf("query = %v, want %v", query, want)
+		}
+		if url := search.listingURL("42"); url != "https://www.asi67.com/vente/vente,42" {
+			t.Errorf("listingURL() = %s, want the sale page", url)
+		}
+	})
+}
+
+func TestSearchAccepts(t *testing.T) {
+	house := func(price, surface float64, rooms int) core.Item {
+		return core.Item{Price: price, RealEstate: &core.RealEstateAttributes{Surface: surface, Rooms: rooms}}
+	}
+
+	sale := Search{Offer: OfferSale, BudgetMin: 150000, BudgetMax: 320000, SurfaceMin: 90, RoomsMin: 4, RoomsMax: 6}
+	rent := Search{Offer: OfferRent, BudgetMin: 600, BudgetMax: 1000, SurfaceMax: 50}
+
+	tests := []struct {
+		name   string
+		search Search
+		item   core.Item
+		want   bool
+	}{
+		{"Within every criterion", sale, house(250000, 120, 5), true},
+		{"Below the minimum budget", sale, house(120000, 120, 5), false},
+		{"Above the maximum sale price", sale, house(350000, 120, 5), false},
+		{"Too small", sale, house(250000, 80, 5), false},
+		{"Too few rooms", sale, house(250000, 120, 3), false},
+		{"Too many rooms", sale, house(250000, 120, 7), false},
+		{"Unpublished surface and rooms", sale, house(250000, 0, 0), true},
+		{"Maximum rent left to the API", rent, house(1200, 40, 2), true},
+		{"Rent below the minimum budget", rent, house(500, 40, 2), false},
+		{"Flat too large", rent, house(800, 65, 3), false},
+		{"Without real-estate attributes", rent, core.Item{Price: 800}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.search.accepts(tt.item); got != tt.want {
+				t.Errorf("accepts(%+v) = %v, want %v", tt.item.RealEstate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchItems(t *testing.T) {
+	// The API publishes 30 items, one per page whatever the page size, and counts the pages requested
+	var pages atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Params struct {
+				Query struct {
+					Page string `json:"page"`
+				} `json:"query"`
+			} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		pages.Add(1)
+		fmt.Fprintf(w, `{"data":{"prodCount":30,"prodId":{"%s":{"city":"Strasbourg","rent_total":800}}}}`, body.Params.Query.Page)
+	}))
+	defer server.Close()
+
+	// A page size below 1 must not crash the fetch, the default page size being used instead
+	items, err := NewProvider(server.URL, -3).FetchItems(context.Background())
+	if err != nil {
+		t.Fatalf("FetchItems() returned an unexpected error: %v", err)
+	}
+	if pages.Load() != 3 || len(items) != 3 {
+		t.Errorf("FetchItems() requested %d pages and returned %d items, want 3 pages of the default size", pages.Load(), len(items))
+	}
+}
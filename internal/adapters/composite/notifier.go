@@ -34,3 +34,71 @@ func (m *CompositeNotifier) Send(ctx context.Context, item core.Item) error {
 	}
 	return nil
 }
+
+// SendBatch dispatches new items to all registered notifiers.
+// Notifiers without a batch rendering receive one Send per item.
+func (m *CompositeNotifier) SendBatch(ctx context.Context, items []core.Item) error {
+	var errs []string
+
+	for _, n := range m.notifiers {
+		if err := core.NotifyBatch(ctx, n, items); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New("notification errors: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// SendChange dispatches an item update to all registered notifiers.
+// Notifiers without a dedicated change rendering receive a plain Send.
+func (m *CompositeNotifier) SendChange(ctx context.Context, change core.ItemChange) error {
+	var errs []string
+
+	for _, n := range m.notifiers {
+		if err := core.NotifyChange(ctx, n, change); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New("notification errors: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// SendRemoval dispatches a "listing gone" notification to all registered notifiers.
+// Notifiers without a dedicated removal rendering are skipped.
+func (m *CompositeNotifier) SendRemoval(ctx context.Context, item core.Item) error {
+	var errs []string
+
+	for _, n := range m.notifiers {
+		if err := core.NotifyRemoval(ctx, n, item); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New("notification errors: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// SendSummary dispatches a bootstrap summary to all registered notifiers.
+// Notifiers without a dedicated summary rendering are skipped.
+func (m *CompositeNotifier) SendSummary(ctx context.Context, summary core.BootstrapSummary) error {
+	var errs []string
+
+	for _, n := range m.notifiers {
+		if err := core.NotifySummary(ctx, n, summary); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New("notification errors: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
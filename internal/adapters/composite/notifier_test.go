@@ -107,3 +107,69 @@ func TestCompositeNotifier_Send(t *testing.T) {
 		}
 	})
 }
+
+type mockChangeNotifier struct {
+	mockNotifier
+	changes int
+}
+
+func (m *mockChangeNotifier) SendChange(ctx context.Context, change core.ItemChange) error {
+	m.changes++
+	return nil
+}
+
+func TestCompositeNotifier_SendChange(t *testing.T) {
+	change := core.ItemChange{Item: core.Item{ID: "test-item"}}
+	ctx := context.Background()
+
+	// Arrange: one notifier renders changes, the other only knows Send
+	n1 := &mockChangeNotifier{}
+	n2 := &mockNotifier{}
+	composite := NewCompositeNotifier(n1, n2)
+
+	// Act
+	err := composite.SendChange(ctx, change)
+
+	// Assert
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if n1.changes != 1 || n1.wasCalled {
+		t.Error("Expected the change-aware notifier to receive SendChange only")
+	}
+	if !n2.wasCalled {
+		t.Error("Expected the plain notifier to fall back to Send")
+	}
+}
+
+func TestCompositeNotifier_SendBatch(t *testing.T) {
+	items := []core.Item{{ID: "1"}, {ID: "2"}}
+	ctx := context.Background()
+
+	t.Run("Fallback: Plain notifiers receive each item", func(t *testing.T) {
+		n1 := &mockNotifier{}
+		composite := NewCompositeNotifier(n1)
+
+		if err := composite.SendBatch(ctx, items); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+		if !n1.wasCalled {
+			t.Error("Expected the plain notifier to fall back to Send")
+		}
+	})
+
+	t.Run("Resilience: A failing notifier fails the batch", func(t *testing.T) {
+		n1 := &mockNotifier{shouldFail: true}
+		n2 := &mockNotifier{}
+		composite := NewCompositeNotifier(n1, n2)
+
+		err := composite.SendBatch(ctx, items)
+
+		if err == nil {
+			t.Error("Expected an error because one notifier failed")
+		}
+		if !n2.wasCalled {
+			t.Error("Notifier 2 should have been called despite Notifier 1 failure")
+		}
+	})
+}
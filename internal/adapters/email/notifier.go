@@ -3,7 +3,10 @@ package email
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
 	"strings"
+	"time"
 
 	"gopkg.in/gomail.v2"
 
@@ -12,11 +15,31 @@ import (
 )
 
 type EmailNotifier struct {
-	cfg config.EmailConfig
+	cfg    config.EmailConfig
+	client *http.Client // Downloads the photos to embed
+	output io.Writer    // Receives the rendered emails instead of the SMTP server, when set
 }
 
-func NewEmailNotifier(cfg config.EmailConfig) *EmailNotifier {
-	return &EmailNotifier{cfg: cfg}
+// Option customizes an EmailNotifier.
+type Option func(*EmailNotifier)
+
+// WithOutput writes the rendered emails to w instead of sending them, for dry runs.
+// Photos are then linked rather than downloaded and embedded.
+func WithOutput(w io.Writer) Option {
+	return func(n *EmailNotifier) {
+		n.output = w
+	}
+}
+
+func NewEmailNotifier(cfg config.EmailConfig, opts ...Option) *EmailNotifier {
+	n := &EmailNotifier{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n
 }
 
 func (n *EmailNotifier) Send(ctx context.Context, item core.Item) error {
@@ -25,33 +48,128 @@ func (n *EmailNotifier) Send(ctx context.Context, item core.Item) error {
 		return ctx.Err()
 	}
 
+	// Subject: e.g., "🔔 New Dog: Rex - Male"
+	subject := fmt.Sprintf("🔔 New Item: %s", item.Title)
+
+	var images []inlineImage
+	photo := n.photo(ctx, item, "photo.jpg", photoWidth, &images)
+
+	if err := n.send(subject, n.buildBody(item, photo), images...); err != nil {
+		return fmt.Errorf("failed to send email for item %s: %w", item.ID, err)
+	}
+	return nil
+}
+
+// SendBatch sends a single digest email listing all the new items of a run.
+func (n *EmailNotifier) SendBatch(ctx context.Context, items []core.Item) error {
+	switch len(items) {
+	case 0:
+		return nil
+	case 1:
+		return n.Send(ctx, items[0])
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	subject := fmt.Sprintf("🔔 New Items (%d)", len(items))
+
+	var images []inlineImage
+	photos := make([]string, len(items))
+	for i, item := range items {
+		photos[i] = n.photo(ctx, item, fmt.Sprintf("photo-%d.jpg", i), digestWidth, &images)
+	}
+
+	if err := n.send(subject, n.buildDigestBody(items, photos), images...); err != nil {
+		return fmt.Errorf("failed to send digest email for %d items: %w", len(items), err)
+	}
+	return nil
+}
+
+// SendChange notifies an update of a known listing, highlighting price moves.
+func (n *EmailNotifier) SendChange(ctx context.Context, change core.ItemChange) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	subject := fmt.Sprintf("✏️ Item Updated: %s", change.Item.Title)
+	if change.PriceDropped() {
+		subject = fmt.Sprintf("📉 Price Drop: %s (%.2f → %.2f %s)",
+			change.Item.Title, change.Previous.Price, change.Item.Price, change.Item.Currency)
+	}
+
+	var images []inlineImage
+	photo := n.photo(ctx, change.Item, "photo.jpg", photoWidth, &images)
+
+	if err := n.send(subject, n.buildChangeBody(change, photo), images...); err != nil {
+		return fmt.Errorf("failed to send change email for item %s: %w", change.Item.ID, err)
+	}
+	return nil
+}
+
+// SendRemoval announces a listing that disappeared (adopted, rented...).
+func (n *EmailNotifier) SendRemoval(ctx context.Context, item core.Item) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	subject := fmt.Sprintf("👋 Listing Gone: %s", item.Title)
+
+	if err := n.send(subject, n.buildRemovalBody(item)); err != nil {
+		return fmt.Errorf("failed to send removal email for item %s: %w", item.ID, err)
+	}
+	return nil
+}
+
+// SendSummary announces that a provider was seeded with its current listings.
+func (n *EmailNotifier) SendSummary(ctx context.Context, summary core.BootstrapSummary) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	subject := fmt.Sprintf("👀 Now Watching: %s (%d listings)", summary.Provider, summary.Items)
+
+	if err := n.send(subject, n.buildSummaryBody(summary)); err != nil {
+		return fmt.Errorf("failed to send summary email for provider %s: %w", summary.Provider, err)
+	}
+	return nil
+}
+
+func (n *EmailNotifier) send(subject, body string, images ...inlineImage) error {
+	if n.output != nil {
+		_, err := fmt.Fprintf(n.output, "From: %s\nTo: %s\nSubject: %s\n\n%s\n\n", n.cfg.From, strings.Join(n.cfg.To, ", "), subject, body)
+		return err
+	}
+
 	m := gomail.NewMessage()
 	m.SetHeader("From", n.cfg.From)
 	m.SetHeader("To", n.cfg.To...)
-
-	// Subject: e.g., "🔔 New Dog: Rex - Male"
-	subject := fmt.Sprintf("🔔 New Item: %s", item.Title)
 	m.SetHeader("Subject", subject)
-
-	// Body construction
-	body := n.buildBody(item)
 	m.SetBody("text/html", body)
 
+	// Inline attachments, referenced by the body as "cid:<name>"
+	for _, img := range images {
+		data := img.data
+		m.Embed(img.name, gomail.SetCopyFunc(func(w io.Writer) error {
+			_, err := w.Write(data)
+			return err
+		}))
+	}
+
 	// SMTP Configuration
 	d := gomail.NewDialer(n.cfg.SMTPHost, n.cfg.SMTPPort, n.cfg.SMTPUser, n.cfg.SMTPPassword)
 
-	// Send
-	if err := d.DialAndSend(m); err != nil {
-		return fmt.Errorf("failed to send email for item %s: %w", item.ID, err)
-	}
-
-	return nil
+	return d.DialAndSend(m)
 }
 
-func (n *EmailNotifier) buildBody(item core.Item) string {
+// buildBody renders a new item. photo is the src of its primary photo, "" when it has none.
+func (n *EmailNotifier) buildBody(item core.Item, photo string) string {
 	var sb strings.Builder
 
 	sb.WriteString("<h2>New Item Discovered!</h2>")
+	writePhoto(&sb, photo, item.Title, photoWidth)
+
 	sb.WriteString("<ul>")
 
 	// Title
@@ -62,20 +180,175 @@ func (n *EmailNotifier) buildBody(item core.Item) string {
 		sb.WriteString(fmt.Sprintf("<li><strong>Price:</strong> %.2f %s</li>", item.Price, item.Currency))
 	}
 
-	// Description
-	sb.WriteString(fmt.Sprintf("<li><strong>Details:</strong> %s</li>", item.Description))
+	// Description: the full one when the listing was enriched
+	details := item.Description
+	if item.FullDescription != "" {
+		details = item.FullDescription
+	}
+	sb.WriteString(fmt.Sprintf("<li><strong>Details:</strong> %s</li>", details))
+
+	// Typed attributes (if applicable)
+	if features := describeAttributes(item); features != "" {
+		sb.WriteString(fmt.Sprintf("<li><strong>Features:</strong> %s</li>", features))
+	}
+	sb.WriteString("</ul>")
+
+	writeButton(&sb, item.Url)
+	writeFooter(&sb)
+
+	return sb.String()
+}
+
+// buildDigestBody renders new items. photos holds the src of their primary photo, "" when they have none.
+func (n *EmailNotifier) buildDigestBody(items []core.Item, photos []string) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("<h2>%d New Items Discovered!</h2>", len(items)))
+	sb.WriteString("<ul>")
+
+	for i, item := range items {
+		sb.WriteString(`<li style="margin-bottom: 10px;">`)
+		if i < len(photos) {
+			writePhoto(&sb, photos[i], item.Title, digestWidth)
+		}
+		sb.WriteString(fmt.Sprintf(`<a href="%s"><strong>%s</strong></a>`, item.Url, item.Title))
+		if item.Price > 0 {
+			sb.WriteString(fmt.Sprintf(" - %.2f %s", item.Price, item.Currency))
+		}
+		if item.Description != "" {
+			sb.WriteString(fmt.Sprintf(`<br/><span style="color: #555;">%s</span>`, item.Description))
+		}
+		if features := describeAttributes(item); features != "" {
+			sb.WriteString(fmt.Sprintf(`<br/><span style="color: #888;">%s</span>`, features))
+		}
+		sb.WriteString("</li>")
+	}
+	sb.WriteString("</ul>")
+
+	writeFooter(&sb)
+
+	return sb.String()
+}
+
+func (n *EmailNotifier) buildChangeBody(change core.ItemChange, photo string) string {
+	var sb strings.Builder
+
+	sb.WriteString("<h2>Item Updated!</h2>")
+	writePhoto(&sb, photo, change.Item.Title, photoWidth)
+	sb.WriteString(fmt.Sprintf("<p><strong>%s</strong></p>", change.Item.Title))
+
+	// Price: old value struck through, new value highlighted
+	if change.Previous.Price != change.Item.Price {
+		color := "#dc3545" // Red: price went up
+		if change.PriceDropped() {
+			color = "#28a745" // Green: price went down
+		}
+		sb.WriteString(fmt.Sprintf(
+			`<p style="font-size: 18px;"><s style="color: #888;">%.2f %s</s> → <strong style="color: %s;">%.2f %s</strong></p>`,
+			change.Previous.Price, change.Previous.Currency, color, change.Item.Price, change.Item.Currency,
+		))
+	}
+
+	sb.WriteString("<ul>")
+	for _, c := range change.Changes {
+		if c.Field == "price" {
+			continue // Already highlighted above
+		}
+		sb.WriteString(fmt.Sprintf("<li><strong>%s:</strong> <s>%s</s> → %s</li>", c.Field, c.Old, c.New))
+	}
 	sb.WriteString("</ul>")
 
-	// Call-to-Action Button
+	writeButton(&sb, change.Item.Url)
+	writeFooter(&sb)
+
+	return sb.String()
+}
+
+func (n *EmailNotifier) buildRemovalBody(item core.Item) string {
+	var sb strings.Builder
+
+	sb.WriteString("<h2>Listing Gone</h2>")
+	sb.WriteString(fmt.Sprintf("<p><strong>%s</strong> is no longer listed on the website.</p>", item.Title))
+	sb.WriteString(fmt.Sprintf(`<p style="color: #888;">Last known link: <a href="%s">%s</a></p>`, item.Url, item.Url))
+
+	writeFooter(&sb)
+
+	return sb.String()
+}
+
+func (n *EmailNotifier) buildSummaryBody(summary core.BootstrapSummary) string {
+	var sb strings.Builder
+
+	sb.WriteString("<h2>Now Watching</h2>")
+	sb.WriteString(fmt.Sprintf("<p><strong>%s</strong> currently lists <strong>%d</strong> items.</p>", summary.Provider, summary.Items))
+	sb.WriteString(`<p style="color: #888;">They were recorded as seen: only new listings will be notified from now on.</p>`)
+
+	writeFooter(&sb)
+
+	return sb.String()
+}
+
+// describeAttributes renders the typed attributes of an item on one line, empty when it has none.
+func describeAttributes(item core.Item) string {
+	var parts []string
+
+	if re := item.RealEstate; re != nil {
+		if re.Surface > 0 {
+			parts = append(parts, fmt.Sprintf("%.0f m²", re.Surface))
+		}
+		if re.Rooms > 0 {
+			parts = append(parts, fmt.Sprintf("%d rooms", re.Rooms))
+		}
+		if re.City != "" {
+			parts = append(parts, strings.TrimSpace(fmt.Sprintf("%s %s", re.PostalCode, re.City)))
+		}
+		if re.Charges > 0 {
+			parts = append(parts, fmt.Sprintf("rent %.2f + charges %.2f %s", re.Rent, re.Charges, item.Currency))
+		}
+		if re.Furnished {
+			parts = append(parts, "furnished")
+		}
+	}
+
+	if animal := item.Animal; animal != nil {
+		for _, value := range []string{animal.Species, string(animal.Sex), animal.Breed} {
+			if value != "" {
+				parts = append(parts, value)
+			}
+		}
+		switch {
+		case animal.AgeMonths >= 24:
+			parts = append(parts, fmt.Sprintf("%d years", animal.AgeMonths/12))
+		case animal.AgeMonths > 0:
+			parts = append(parts, fmt.Sprintf("%d months", animal.AgeMonths))
+		}
+		if animal.Urgent {
+			parts = append(parts, `<strong style="color: #dc3545;">URGENT</strong>`)
+		}
+	}
+
+	return strings.Join(parts, " · ")
+}
+
+// writePhoto appends a photo no wider than width, nothing when src is empty.
+func writePhoto(sb *strings.Builder, src, alt string, width int) {
+	if src == "" {
+		return
+	}
+	sb.WriteString(fmt.Sprintf(`<p><img src="%s" alt="%s" width="%d" style="max-width: 100%%; border-radius: 5px;"/></p>`, src, alt, width))
+}
+
+// writeButton appends the Call-to-Action button linking to the listing.
+func writeButton(sb *strings.Builder, url string) {
 	sb.WriteString(fmt.Sprintf(`
 		<br/>
 		<a href="%s" style="background-color: #007bff; color: white; padding: 10px 20px; text-decoration: none; border-radius: 5px; font-family: Arial, sans-serif;">
 			View Item on Website
 		</a>
 		<br/><br/>
-	`, item.Url))
+	`, url))
+}
 
+func writeFooter(sb *strings.Builder) {
 	sb.WriteString("<p style='color: #888; font-size: 12px;'>Sent by Go Watcher</p>")
-
-	return sb.String()
 }
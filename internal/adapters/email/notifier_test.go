@@ -24,7 +24,7 @@ func TestEmailNotifier_buildBody(t *testing.T) {
 		Url:         "https://test.com/guitar",
 	}
 
-	body := notifier.buildBody(item)
+	body := notifier.buildBody(item, "")
 
 	// We verify that critical information is present in the generated HTML.
 
@@ -48,6 +48,26 @@ func TestEmailNotifier_buildBody(t *testing.T) {
 	}
 }
 
+// TestEmailNotifier_buildBody_Enriched verifies that the details of an enriched item take precedence.
+func TestEmailNotifier_buildBody_Enriched(t *testing.T) {
+	notifier := NewEmailNotifier(config.EmailConfig{})
+
+	body := notifier.buildBody(core.Item{
+		Title:           "Rex",
+		Description:     "Short blurb",
+		FullDescription: "Rex is a 3 years old shepherd who loves long walks",
+		Images:          []string{"https://test.com/rex.jpg", "https://test.com/rex-2.jpg"},
+		Url:             "https://test.com/rex",
+	}, "cid:photo.jpg")
+
+	if !strings.Contains(body, "loves long walks") || strings.Contains(body, "Short blurb") {
+		t.Error("Email body should show the full description instead of the blurb")
+	}
+	if !strings.Contains(body, `<img src="cid:photo.jpg"`) || strings.Contains(body, "rex-2.jpg") {
+		t.Error("Email body should show the embedded primary photo only")
+	}
+}
+
 // TestEmailNotifier_Send_ContextCancelled ensures that the Send method
 // respects the context cancellation and aborts immediately.
 // This is a safe way to test part of the Send method without triggering a real network call.
@@ -75,3 +95,106 @@ func TestEmailNotifier_Send_ContextCancelled(t *testing.T) {
 		t.Errorf("Expected context.Canceled error, got: %v", err)
 	}
 }
+
+// TestEmailNotifier_Send_Output verifies that dry runs render the email instead of sending it.
+func TestEmailNotifier_Send_Output(t *testing.T) {
+	var out strings.Builder
+	notifier := NewEmailNotifier(config.EmailConfig{
+		SMTPHost: "smtp.example.com", // Dummy host, should not be reached
+		From:     "watcher@example.com",
+		To:       []string{"alice@example.com", "bob@example.com"},
+	}, WithOutput(&out))
+
+	err := notifier.Send(context.Background(), core.Item{
+		Title:  "Rex",
+		Url:    "https://test.com/rex",
+		Images: []string{"https://test.com/rex.jpg"},
+	})
+	if err != nil {
+		t.Fatalf("Send() returned an unexpected error: %v", err)
+	}
+
+	for _, want := range []string{"To: alice@example.com, bob@example.com", "Subject: 🔔 New Item: Rex", `<img src="https://test.com/rex.jpg"`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Rendered email missing %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+// TestEmailNotifier_buildChangeBody verifies that price moves are highlighted in update emails.
+func TestEmailNotifier_buildChangeBody(t *testing.T) {
+	notifier := NewEmailNotifier(config.EmailConfig{})
+
+	previous := core.Item{
+		ID:          "123",
+		Title:       "T2 Schiltigheim",
+		Description: "45 m²",
+		Price:       900,
+		Currency:    "EUR",
+		Url:         "https://test.com/t2",
+	}
+	current := previous
+	current.Price = 850
+	current.Description = "45 m², balcony"
+
+	change := core.ItemChange{Item: current, Previous: previous, Changes: core.Diff(previous, current)}
+
+	body := notifier.buildChangeBody(change, "")
+
+	tests := []struct {
+		name     string
+		contains string
+	}{
+		{"Old price struck through", "<s style=\"color: #888;\">900.00 EUR</s>"},
+		{"New price highlighted", "850.00 EUR</strong>"},
+		{"Price drop color", "#28a745"},
+		{"Other field changes", "<s>45 m²</s> → 45 m², balcony"},
+		{"Link presence", "https://test.com/t2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(body, tt.contains) {
+				t.Errorf("Email body missing expected content: '%s'", tt.contains)
+			}
+		})
+	}
+}
+
+// TestEmailNotifier_buildDigestBody verifies that a digest lists every item of the run.
+func TestEmailNotifier_buildDigestBody(t *testing.T) {
+	notifier := NewEmailNotifier(config.EmailConfig{})
+
+	items := []core.Item{
+		{ID: "1", Title: "Rex", Description: "Berger allemand", Url: "https://test.com/rex"},
+		{ID: "2", Title: "T2 Bischheim", Price: 650, Currency: "EUR", Url: "https://test.com/t2",
+			RealEstate: &core.RealEstateAttributes{Surface: 42, Rooms: 2, City: "Bischheim", PostalCode: "67800"}},
+		{ID: "3", Title: "Mina", Url: "https://test.com/mina",
+			Animal: &core.AnimalAttributes{Species: "cat", Sex: core.SexFemale, AgeMonths: 8, Urgent: true}},
+	}
+
+	body := notifier.buildDigestBody(items, []string{"", "https://test.com/t2.jpg", "cid:photo-2.jpg"})
+
+	tests := []struct {
+		name     string
+		contains string
+	}{
+		{"Item count", "<h2>3 New Items Discovered!</h2>"},
+		{"First item link", `<a href="https://test.com/rex"><strong>Rex</strong></a>`},
+		{"First item description", "Berger allemand"},
+		{"Second item price", "T2 Bischheim</strong></a> - 650.00 EUR"},
+		{"Real estate attributes", "42 m² · 2 rooms · 67800 Bischheim"},
+		{"Animal attributes", "cat · female · 8 months · "},
+		{"Urgency badge", "URGENT"},
+		{"Remote photo fallback", `<img src="https://test.com/t2.jpg" alt="T2 Bischheim" width="160"`},
+		{"Embedded photo", `<img src="cid:photo-2.jpg"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(body, tt.contains) {
+				t.Errorf("Email body missing expected content: '%s'", tt.contains)
+			}
+		})
+	}
+}
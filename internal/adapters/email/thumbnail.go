@@ -0,0 +1,130 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"net/http"
+
+	"github.com/maximerauch/go-classifieds-watcher/internal/core"
+
+	// Decoders of the formats served by the providers
+	_ "image/gif"
+	_ "image/png"
+)
+
+const (
+	photoWidth       = 480 // Thumbnail of a single listing
+	digestWidth      = 160 // Thumbnails of a digest
+	maxPhotoBytes    = 10 << 20
+	maxPhotoPixels   = 25_000_000 // Decoded size, as a small compressed file may hold a huge picture
+	thumbnailQuality = 80
+)
+
+// inlineImage is a picture embedded in an email, referenced as "cid:<name>" by the body.
+type inlineImage struct {
+	name string
+	data []byte
+}
+
+// thumbnail downloads an image and returns it as a JPEG no wider than width.
+func (n *EmailNotifier) thumbnail(ctx context.Context, url string, width int) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; ClassifiedsWatcher/1.0)")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("image status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPhotoBytes))
+	if err != nil {
+		return nil, fmt.Errorf("image read error: %w", err)
+	}
+
+	// Check the dimensions from the header before decoding the pixels
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("image decode error: %w", err)
+	}
+	if pixels := int64(cfg.Width) * int64(cfg.Height); pixels > maxPhotoPixels {
+		return nil, fmt.Errorf("image of %dx%d pixels exceeds %d pixels", cfg.Width, cfg.Height, maxPhotoPixels)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("image decode error: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, resize(src, width), &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, fmt.Errorf("image encode error: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// resize scales src down to width, averaging the source pixels covered by each target pixel.
+// Transparent areas are flattened on white, as JPEG has no alpha channel.
+func resize(src image.Image, width int) image.Image {
+	b := src.Bounds()
+	if b.Dx() <= width {
+		width = b.Dx()
+	}
+	height := max(1, b.Dy()*width/b.Dx())
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := b.Min.Y+y*b.Dy()/height, b.Min.Y+(y+1)*b.Dy()/height
+		for x := 0; x < width; x++ {
+			x0, x1 := b.Min.X+x*b.Dx()/width, b.Min.X+(x+1)*b.Dx()/width
+
+			var r, g, bl, a, count uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					count++
+				}
+			}
+
+			// Colors are alpha-premultiplied: compositing over white adds the uncovered part
+			white := 0xffff - a/count
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r/count + white),
+				G: uint16(g/count + white),
+				B: uint16(bl/count + white),
+				A: 0xffff,
+			})
+		}
+	}
+	return dst
+}
+
+// photo returns the src of the primary photo of an item, embedding its thumbnail under name.
+// It falls back to the remote URL when the photo cannot be downloaded, and returns "" without photo.
+func (n *EmailNotifier) photo(ctx context.Context, item core.Item, name string, width int, images *[]inlineImage) string {
+	if len(item.Images) == 0 {
+		return ""
+	}
+	if n.output != nil {
+		return item.Images[0]
+	}
+	data, err := n.thumbnail(ctx, item.Images[0], width)
+	if err != nil {
+		return item.Images[0]
+	}
+	*images = append(*images, inlineImage{name: name, data: data})
+	return "cid:" + name
+}
@@ -0,0 +1,120 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/maximerauch/go-classifieds-watcher/internal/config"
+	"github.com/maximerauch/go-classifieds-watcher/internal/core"
+)
+
+func TestResize(t *testing.T) {
+	// Left half red, right half fully transparent
+	src := image.NewNRGBA(image.Rect(0, 0, 1000, 500))
+	for y := 0; y < 500; y++ {
+		for x := 0; x < 500; x++ {
+			src.Set(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+
+	dst := resize(src, 100)
+
+	if got := dst.Bounds(); got.Dx() != 100 || got.Dy() != 50 {
+		t.Fatalf("resize() bounds = %v, want 100x50 keeping the ratio", got)
+	}
+	if r, g, b, _ := dst.At(10, 10).RGBA(); r>>8 != 255 || g>>8 != 0 || b>>8 != 0 {
+		t.Errorf("Opaque area = (%d, %d, %d), want red", r>>8, g>>8, b>>8)
+	}
+	if r, g, b, _ := dst.At(90, 10).RGBA(); r>>8 != 255 || g>>8 != 255 || b>>8 != 255 {
+		t.Errorf("Transparent area = (%d, %d, %d), want white", r>>8, g>>8, b>>8)
+	}
+
+	// Small images are not upscaled
+	if got := resize(image.NewRGBA(image.Rect(0, 0, 40, 30)), 100).Bounds(); got.Dx() != 40 {
+		t.Errorf("resize() width = %d, want the original 40", got.Dx())
+	}
+}
+
+func TestEmailNotifier_photo(t *testing.T) {
+	var picture bytes.Buffer
+	if err := png.Encode(&picture, image.NewRGBA(image.Rect(0, 0, 1200, 800))); err != nil {
+		t.Fatalf("Failed to encode picture: %v", err)
+	}
+
+	// A tiny file whose header announces 100000x100000 pixels
+	var bomb bytes.Buffer
+	if err := png.Encode(&bomb, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatalf("Failed to encode picture: %v", err)
+	}
+	header := bomb.Bytes()[8:33] // IHDR chunk: length, type, data, CRC
+	binary.BigEndian.PutUint32(header[8:], 100000)
+	binary.BigEndian.PutUint32(header[12:], 100000)
+	binary.BigEndian.PutUint32(header[21:], crc32.ChecksumIEEE(header[4:21]))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rex.png":
+			_, _ = w.Write(picture.Bytes())
+		case "/bomb.png":
+			_, _ = w.Write(bomb.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	notifier := NewEmailNotifier(config.EmailConfig{})
+	ctx := context.Background()
+
+	t.Run("Embeds a thumbnail", func(t *testing.T) {
+		var images []inlineImage
+		src := notifier.photo(ctx, core.Item{Images: []string{server.URL + "/rex.png"}}, "photo.jpg", photoWidth, &images)
+
+		if src != "cid:photo.jpg" || len(images) != 1 {
+			t.Fatalf("photo() = %q with %d images, want an embedded thumbnail", src, len(images))
+		}
+		thumb, err := jpeg.Decode(bytes.NewReader(images[0].data))
+		if err != nil {
+			t.Fatalf("Thumbnail is not a JPEG: %v", err)
+		}
+		if thumb.Bounds().Dx() != photoWidth {
+			t.Errorf("Thumbnail width = %d, want %d", thumb.Bounds().Dx(), photoWidth)
+		}
+	})
+
+	t.Run("Falls back to the remote URL", func(t *testing.T) {
+		var images []inlineImage
+		url := server.URL + "/missing.png"
+		if src := notifier.photo(ctx, core.Item{Images: []string{url}}, "photo.jpg", photoWidth, &images); src != url || len(images) != 0 {
+			t.Errorf("photo() = %q with %d images, want the remote URL", src, len(images))
+		}
+	})
+
+	t.Run("Falls back to the remote URL for oversized images", func(t *testing.T) {
+		var images []inlineImage
+		url := server.URL + "/bomb.png"
+		if src := notifier.photo(ctx, core.Item{Images: []string{url}}, "photo.jpg", photoWidth, &images); src != url || len(images) != 0 {
+			t.Errorf("photo() = %q with %d images, want the remote URL", src, len(images))
+		}
+		if _, err := notifier.thumbnail(ctx, url, photoWidth); err == nil || !strings.Contains(err.Error(), "exceeds") {
+			t.Errorf("thumbnail() error = %v, want the image to exceed the pixel budget", err)
+		}
+	})
+
+	t.Run("Items without photo", func(t *testing.T) {
+		var images []inlineImage
+		if src := notifier.photo(ctx, core.Item{}, "photo.jpg", photoWidth, &images); src != "" {
+			t.Errorf("photo() = %q, want no photo", src)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package fs
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/maximerauch/go-classifieds-watcher/internal/core"
+)
+
+type JSONOutbox struct {
+	filePath   string
+	mu         sync.Mutex
+	deliveries map[string]core.Delivery // Keyed by delivery ID
+}
+
+func NewJSONOutbox(filePath string) *JSONOutbox {
+	outbox := &JSONOutbox{
+		filePath:   filePath,
+		deliveries: make(map[string]core.Delivery),
+	}
+	outbox.load()
+	return outbox
+}
+
+func (o *JSONOutbox) Enqueue(ctx context.Context, deliveries []core.Delivery) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for _, d := range deliveries {
+		if _, exists := o.deliveries[d.ID]; !exists {
+			o.deliveries[d.ID] = d
+		}
+	}
+	return o.save()
+}
+
+func (o *JSONOutbox) Due(ctx context.Context, now time.Time) ([]core.Delivery, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var due []core.Delivery
+	for _, d := range o.deliveries {
+		if d.Status == core.DeliveryPending && !d.NextAttemptAt.After(now) {
+			due = append(due, d)
+		}
+	}
+
+	// Map iteration is random: keep a stable order across runs
+	sort.Slice(due, func(i, j int) bool { return due[i].ID < due[j].ID })
+	return due, nil
+}
+
+func (o *JSONOutbox) Update(ctx context.Context, delivery core.Delivery) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.deliveries[delivery.ID] = delivery
+	return o.save()
+}
+
+// Prune deletes the sent and dead-lettered deliveries completed before cutoff, with a single file write.
+func (o *JSONOutbox) Prune(ctx context.Context, cutoff time.Time) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	pruned := 0
+	for id, d := range o.deliveries {
+		if d.Status != core.DeliveryPending && d.CompletedAt.Before(cutoff) {
+			delete(o.deliveries, id)
+			pruned++
+		}
+	}
+	if pruned == 0 {
+		return 0, nil
+	}
+	return pruned, o.save()
+}
+
+func (o *JSONOutbox) load() {
+	file, err := os.ReadFile(o.filePath)
+	if err != nil {
+		return // Start with empty map if file doesn't exist
+	}
+	_ = json.Unmarshal(file, &o.deliveries)
+
+	// Files written before retention do not date completions: their retention starts now
+	now := time.Now()
+	for id, d := range o.deliveries {
+		if d.Status != core.DeliveryPending && d.CompletedAt.IsZero() {
+			d.CompletedAt = now
+			o.deliveries[id] = d
+		}
+	}
+}
+
+func (o *JSONOutbox) save() error {
+	data, err := json.MarshalIndent(o.deliveries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(o.filePath, data, 0644)
+}
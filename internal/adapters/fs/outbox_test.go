@@ -0,0 +1,106 @@
+package fs
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maximerauch/go-classifieds-watcher/internal/core"
+)
+
+func TestJSONOutbox(t *testing.T) {
+	outboxPath := filepath.Join(t.TempDir(), "outbox.json")
+	ctx := context.Background()
+	now := time.Now()
+
+	pending := core.Delivery{
+		ID:            "email|new|p/1",
+		Channel:       "email",
+		Kind:          core.DeliveryNew,
+		Item:          core.Item{ID: "1", Provider: "p", Title: "Rex"},
+		Status:        core.DeliveryPending,
+		NextAttemptAt: now,
+	}
+	later := pending
+	later.ID = "email|new|p/2"
+	later.NextAttemptAt = now.Add(time.Hour)
+
+	// Scenario 1: Deduplication
+	// Enqueueing an already stored delivery must not reset its state.
+	t.Run("Ignore already enqueued deliveries", func(t *testing.T) {
+		outbox := NewJSONOutbox(outboxPath)
+		if err := outbox.Enqueue(ctx, []core.Delivery{pending, later}); err != nil {
+			t.Fatalf("Failed to enqueue: %v", err)
+		}
+
+		sent := pending
+		sent.Status = core.DeliverySent
+		if err := outbox.Update(ctx, sent); err != nil {
+			t.Fatalf("Failed to update: %v", err)
+		}
+
+		if err := outbox.Enqueue(ctx, []core.Delivery{pending}); err != nil {
+			t.Fatalf("Failed to enqueue: %v", err)
+		}
+
+		due, _ := outbox.Due(ctx, now.Add(2*time.Hour))
+		if len(due) != 1 || due[0].ID != later.ID {
+			t.Errorf("Expected only the other delivery to be due, got %v", due)
+		}
+	})
+
+	// Scenario 2: Persistence and scheduling
+	// A fresh instance reads the file and honours the next attempt date.
+	t.Run("Reload deliveries from disk", func(t *testing.T) {
+		outbox := NewJSONOutbox(outboxPath)
+
+		due, err := outbox.Due(ctx, now)
+		if err != nil {
+			t.Fatalf("Unexpected error reading due deliveries: %v", err)
+		}
+		if len(due) != 0 {
+			t.Errorf("Expected no delivery due before its next attempt, got %v", due)
+		}
+
+		due, _ = outbox.Due(ctx, now.Add(2*time.Hour))
+		if len(due) != 1 || due[0].Item.Title != "Rex" {
+			t.Errorf("Expected the delayed delivery with its item, got %v", due)
+		}
+	})
+	// Scenario 3: Retention
+	// Completed deliveries are pruned once old enough, pending ones are kept whatever their age.
+	t.Run("Prune completed deliveries", func(t *testing.T) {
+		outbox := NewJSONOutbox(filepath.Join(t.TempDir(), "outbox.json"))
+		old := now.Add(-48 * time.Hour)
+
+		sent, failed, recent, waiting := pending, pending, pending, pending
+		sent.ID, sent.Status, sent.CompletedAt = "sent", core.DeliverySent, old
+		failed.ID, failed.Status, failed.CompletedAt = "failed", core.DeliveryFailed, old
+		recent.ID, recent.Status, recent.CompletedAt = "recent", core.DeliverySent, now
+		waiting.ID, waiting.NextAttemptAt = "waiting", old
+		if err := outbox.Enqueue(ctx, []core.Delivery{sent, failed, recent, waiting}); err != nil {
+			t.Fatalf("Failed to enqueue: %v", err)
+		}
+
+		pruned, err := outbox.Prune(ctx, now.Add(-24*time.Hour))
+		if err != nil || pruned != 2 {
+			t.Fatalf("Prune() = %d, %v; want the 2 old completed deliveries", pruned, err)
+		}
+
+		// The file holds the remaining deliveries only
+		reloaded := NewJSONOutbox(outbox.filePath)
+		if len(reloaded.deliveries) != 2 || reloaded.deliveries["recent"].Status != core.DeliverySent || reloaded.deliveries["waiting"].Status != core.DeliveryPending {
+			t.Errorf("Deliveries = %v, want the recent and pending ones", reloaded.deliveries)
+		}
+	})
+
+	// Scenario 4: Legacy files
+	// Completed deliveries of files written without completion times are kept for a whole retention period.
+	t.Run("Date legacy completed deliveries on load", func(t *testing.T) {
+		outbox := NewJSONOutbox(outboxPath)
+		if pruned, err := outbox.Prune(ctx, now); err != nil || pruned != 0 {
+			t.Errorf("Prune() = %d, %v; want the legacy sent delivery to be kept", pruned, err)
+		}
+	})
+}
@@ -3,40 +3,178 @@ package fs
 import (
 	"context"
 	"encoding/json"
+	"maps"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/maximerauch/go-classifieds-watcher/internal/core"
 )
 
+// record is the stored form of an item.
+// Embedding the item keeps older files readable: they decode as records without tracking data.
+type record struct {
+	core.Item
+	LastSeen     *time.Time `json:",omitempty"`
+	MissingCount int        `json:",omitempty"`
+	RemovedAt    *time.Time `json:",omitempty"`
+}
+
 type JSONRepository struct {
 	filePath string
 	mu       sync.Mutex
-	storage  map[string]core.Item
+	storage  map[string]record // Keyed by core.ItemKey.String()
 }
 
 func NewJSONRepository(filePath string) *JSONRepository {
 	repo := &JSONRepository{
 		filePath: filePath,
-		storage:  make(map[string]core.Item),
+		storage:  make(map[string]record),
 	}
 	repo.load()
 	return repo
 }
 
-func (r *JSONRepository) Exists(ctx context.Context, id string) (bool, error) {
+func (r *JSONRepository) Exists(ctx context.Context, key core.ItemKey) (bool, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	_, exists := r.storage[id]
+	_, exists := r.find(key)
 	return exists, nil
 }
 
+// CountItems returns how many items of a provider are stored.
+// Legacy unscoped entries are counted for every provider, as they predate provider scoping.
+func (r *JSONRepository) CountItems(ctx context.Context, provider string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := 0
+	for _, rec := range r.storage {
+		if rec.Provider == provider || rec.Provider == "" {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Get returns the stored snapshot of an item.
+func (r *JSONRepository) Get(ctx context.Context, key core.ItemKey) (core.Item, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if storageKey, exists := r.find(key); exists {
+		return r.storage[storageKey].Item, true, nil
+	}
+	return core.Item{}, false, nil
+}
+
 func (r *JSONRepository) Save(ctx context.Context, item core.Item) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	r.storage[item.ID] = item
-	return r.save()
+	return r.commit(func(storage map[string]record) {
+		store(storage, item)
+	})
+}
+
+// ExistsMany checks all keys at once.
+func (r *JSONRepository) ExistsMany(ctx context.Context, keys []core.ItemKey) (map[core.ItemKey]bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	exists := make(map[core.ItemKey]bool, len(keys))
+	for _, key := range keys {
+		if _, ok := r.find(key); ok {
+			exists[key] = true
+		}
+	}
+	return exists, nil
+}
+
+// GetMany returns the snapshots of all known keys at once.
+func (r *JSONRepository) GetMany(ctx context.Context, keys []core.ItemKey) (map[core.ItemKey]core.Item, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshots := make(map[core.ItemKey]core.Item, len(keys))
+	for _, key := range keys {
+		if storageKey, ok := r.find(key); ok {
+			snapshots[key] = r.storage[storageKey].Item
+		}
+	}
+	return snapshots, nil
+}
+
+// SaveMany stores all items with a single file write, none of them being seen when it fails.
+func (r *JSONRepository) SaveMany(ctx context.Context, items []core.Item) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.commit(func(storage map[string]record) {
+		for _, item := range items {
+			store(storage, item)
+		}
+	})
+}
+
+// store refreshes the snapshot of an item, keeping its presence tracking data.
+func store(storage map[string]record, item core.Item) {
+	storageKey := item.Key().String()
+	rec := storage[storageKey]
+	rec.Item = item
+	storage[storageKey] = rec
+}
+
+// Reconcile records the items observed by a complete scan of a provider.
+func (r *JSONRepository) Reconcile(ctx context.Context, provider string, seen []core.ItemKey, threshold int, at time.Time) ([]core.Item, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var removed []core.Item
+	err := r.commit(func(storage map[string]record) {
+		observed := make(map[string]bool, len(seen))
+		for _, key := range seen {
+			storageKey, exists := r.find(key)
+			if !exists {
+				continue
+			}
+			observed[storageKey] = true
+
+			rec := storage[storageKey]
+			rec.LastSeen = &at
+			rec.MissingCount = 0
+			rec.RemovedAt = nil
+			storage[storageKey] = rec
+		}
+
+		for storageKey, rec := range storage {
+			if rec.Provider != provider || observed[storageKey] || rec.RemovedAt != nil {
+				continue
+			}
+
+			rec.MissingCount++
+			if rec.MissingCount >= threshold {
+				rec.RemovedAt = &at
+				removed = append(removed, rec.Item)
+			}
+			storage[storageKey] = rec
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// find returns the storage key under which an item is known.
+func (r *JSONRepository) find(key core.ItemKey) (string, bool) {
+	if _, exists := r.storage[key.String()]; exists {
+		return key.String(), true
+	}
+
+	// Files written before provider scoping hold a single source each,
+	// so an unscoped entry matches whatever provider asks for it.
+	legacyKey := core.ItemKey{ID: key.ID}.String()
+	_, exists := r.storage[legacyKey]
+	return legacyKey, exists
 }
 
 func (r *JSONRepository) load() {
@@ -44,13 +182,31 @@ func (r *JSONRepository) load() {
 	if err != nil {
 		return // Start with empty map if file doesn't exist
 	}
-	_ = json.Unmarshal(file, &r.storage)
+
+	var stored map[string]record
+	if err := json.Unmarshal(file, &stored); err != nil {
+		return
+	}
+
+	// Re-key entries from the item itself: legacy files were keyed by raw ID
+	for _, rec := range stored {
+		r.storage[rec.Key().String()] = rec
+	}
 }
 
-func (r *JSONRepository) save() error {
-	data, err := json.MarshalIndent(r.storage, "", "  ")
+// commit applies update to a copy of the stored items, swapped in once written to the file:
+// when the write fails, the repository is left as it was.
+func (r *JSONRepository) commit(update func(storage map[string]record)) error {
+	storage := maps.Clone(r.storage)
+	update(storage)
+
+	data, err := json.MarshalIndent(storage, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(r.filePath, data, 0644)
+	if err := os.WriteFile(r.filePath, data, 0644); err != nil {
+		return err
+	}
+	r.storage = storage
+	return nil
 }
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/maximerauch/go-classifieds-watcher/internal/core"
 )
@@ -17,16 +18,17 @@ func TestJSONRepository(t *testing.T) {
 	ctx := context.Background()
 
 	item := core.Item{
-		ID:    "xyz-123",
-		Title: "Test Item",
-		Price: 100,
+		ID:       "xyz-123",
+		Provider: "test-provider",
+		Title:    "Test Item",
+		Price:    100,
 	}
 
 	// Scenario 1: Fresh start
 	// Ensure a new repository works even if the file doesn't exist yet.
 	t.Run("Initialize with missing file", func(t *testing.T) {
 		repo := NewJSONRepository(dbPath)
-		exists, err := repo.Exists(ctx, "any-id")
+		exists, err := repo.Exists(ctx, core.ItemKey{Provider: "test-provider", ID: "any-id"})
 		if err != nil {
 			t.Fatalf("Unexpected error checking existence: %v", err)
 		}
@@ -43,7 +45,7 @@ func TestJSONRepository(t *testing.T) {
 			t.Fatalf("Failed to save item: %v", err)
 		}
 
-		exists, _ := repo.Exists(ctx, item.ID)
+		exists, _ := repo.Exists(ctx, item.Key())
 		if !exists {
 			t.Error("Item should exist in memory after save")
 		}
@@ -61,7 +63,7 @@ func TestJSONRepository(t *testing.T) {
 		// Create a fresh instance to simulate a restart
 		repo := NewJSONRepository(dbPath)
 
-		exists, err := repo.Exists(ctx, item.ID)
+		exists, err := repo.Exists(ctx, item.Key())
 		if err != nil {
 			t.Fatalf("Error checking existence after reload: %v", err)
 		}
@@ -84,9 +86,199 @@ func TestJSONRepository(t *testing.T) {
 		repo := NewJSONRepository(dbPath)
 
 		// Should effectively be empty
-		exists, _ := repo.Exists(ctx, item.ID)
+		exists, _ := repo.Exists(ctx, item.Key())
 		if exists {
 			t.Error("Repository should ignore corrupt data and start empty")
 		}
 	})
+
+	// Scenario 5: Provider scoping
+	// The same raw ID coming from another provider is a different item.
+	t.Run("Scope identity by provider", func(t *testing.T) {
+		repo := NewJSONRepository(filepath.Join(tmpDir, "scoped-db.json"))
+		if err := repo.Save(ctx, item); err != nil {
+			t.Fatalf("Failed to save item: %v", err)
+		}
+
+		exists, _ := repo.Exists(ctx, core.ItemKey{Provider: "another-provider", ID: item.ID})
+		if exists {
+			t.Error("Item from another provider should not be considered as seen")
+		}
+	})
+
+	// Scenario 6: Legacy file format
+	// Files written before provider scoping are keyed by raw ID and hold one source each.
+	t.Run("Read legacy unscoped file", func(t *testing.T) {
+		legacyPath := filepath.Join(tmpDir, "legacy-db.json")
+		legacy := []byte(`{"pet-42": {"ID": "pet-42", "Title": "Rex"}}`)
+		if err := os.WriteFile(legacyPath, legacy, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		repo := NewJSONRepository(legacyPath)
+
+		exists, _ := repo.Exists(ctx, core.ItemKey{Provider: "remember-me-france", ID: "pet-42"})
+		if !exists {
+			t.Error("Legacy entry should still be considered as seen")
+		}
+	})
+
+	// Scenario 7: Snapshots
+	// Saving a known item again replaces the stored snapshot.
+	t.Run("Return the latest snapshot", func(t *testing.T) {
+		repo := NewJSONRepository(filepath.Join(tmpDir, "snapshot-db.json"))
+		if err := repo.Save(ctx, item); err != nil {
+			t.Fatalf("Failed to save item: %v", err)
+		}
+
+		updated := item
+		updated.Price = 80
+		if err := repo.Save(ctx, updated); err != nil {
+			t.Fatalf("Failed to save updated item: %v", err)
+		}
+
+		snapshot, exists, err := repo.Get(ctx, item.Key())
+		if err != nil {
+			t.Fatalf("Unexpected error reading snapshot: %v", err)
+		}
+		if !exists {
+			t.Fatal("Item should be known")
+		}
+		if snapshot.Price != 80 {
+			t.Errorf("Snapshot price = %.2f, want 80", snapshot.Price)
+		}
+	})
+}
+
+func TestJSONRepository_Reconcile(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "presence-db.json")
+	ctx := context.Background()
+	now := time.Now()
+
+	gone := core.Item{ID: "gone", Provider: "test-provider", Title: "Adopted"}
+	kept := core.Item{ID: "kept", Provider: "test-provider", Title: "Still there"}
+	other := core.Item{ID: "other", Provider: "another-provider", Title: "Not scanned"}
+
+	repo := NewJSONRepository(dbPath)
+	for _, item := range []core.Item{gone, kept, other} {
+		if err := repo.Save(ctx, item); err != nil {
+			t.Fatalf("Failed to save item: %v", err)
+		}
+	}
+
+	scan := []core.ItemKey{kept.Key()}
+
+	// First miss: below the threshold of 2
+	removed, err := repo.Reconcile(ctx, "test-provider", scan, 2, now)
+	if err != nil {
+		t.Fatalf("Reconcile() failed: %v", err)
+	}
+	if len(removed) != 0 {
+		t.Fatalf("Expected no removal after one miss, got %v", removed)
+	}
+
+	// Second miss, after a restart to prove the counter is persisted
+	repo = NewJSONRepository(dbPath)
+	removed, err = repo.Reconcile(ctx, "test-provider", scan, 2, now)
+	if err != nil {
+		t.Fatalf("Reconcile() failed: %v", err)
+	}
+	if len(removed) != 1 || removed[0].ID != "gone" {
+		t.Fatalf("Expected only 'gone' to be removed, got %v", removed)
+	}
+
+	// Already removed items are reported once
+	removed, _ = repo.Reconcile(ctx, "test-provider", scan, 2, now)
+	if len(removed) != 0 {
+		t.Errorf("Expected removed item to be reported once, got %v", removed)
+	}
+
+	// Removed items are still known, so a reappearance is not notified as new
+	if exists, _ := repo.Exists(ctx, gone.Key()); !exists {
+		t.Error("Removed item should still be known")
+	}
+}
+
+func TestJSONRepository_Batch(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "batch-db.json")
+	ctx := context.Background()
+
+	items := []core.Item{
+		{ID: "1", Provider: "test-provider", Title: "First"},
+		{ID: "2", Provider: "test-provider", Title: "Second"},
+	}
+	unknown := core.ItemKey{Provider: "test-provider", ID: "3"}
+
+	repo := NewJSONRepository(dbPath)
+	if err := repo.SaveMany(ctx, items); err != nil {
+		t.Fatalf("SaveMany() failed: %v", err)
+	}
+
+	// Reload to prove the batch reached the disk
+	repo = NewJSONRepository(dbPath)
+	keys := []core.ItemKey{items[0].Key(), items[1].Key(), unknown}
+
+	exists, err := repo.ExistsMany(ctx, keys)
+	if err != nil {
+		t.Fatalf("ExistsMany() failed: %v", err)
+	}
+	if !exists[keys[0]] || !exists[keys[1]] || exists[unknown] {
+		t.Errorf("ExistsMany() = %v, want the two saved keys only", exists)
+	}
+
+	snapshots, err := repo.GetMany(ctx, keys)
+	if err != nil {
+		t.Fatalf("GetMany() failed: %v", err)
+	}
+	if len(snapshots) != 2 || snapshots[keys[1]].Title != "Second" {
+		t.Errorf("GetMany() = %v, want the snapshots of the two saved keys", snapshots)
+	}
+}
+
+func TestJSONRepository_FailedWrite(t *testing.T) {
+	// The directory of the file does not exist, so that every write fails
+	dbPath := filepath.Join(t.TempDir(), "missing", "db.json")
+	ctx := context.Background()
+
+	repo := NewJSONRepository(dbPath)
+	items := []core.Item{
+		{ID: "1", Provider: "test-provider", Title: "First"},
+		{ID: "2", Provider: "test-provider", Title: "Second"},
+	}
+	if err := repo.SaveMany(ctx, items); err == nil {
+		t.Fatal("SaveMany() should fail when the file cannot be written")
+	}
+
+	// None of the items may count as seen, so that the next run tries them again
+	exists, err := repo.ExistsMany(ctx, []core.ItemKey{items[0].Key(), items[1].Key()})
+	if err != nil {
+		t.Fatalf("ExistsMany() failed: %v", err)
+	}
+	if len(exists) != 0 {
+		t.Errorf("ExistsMany() = %v, want no item after a failed write", exists)
+	}
+	if count, _ := repo.CountItems(ctx, "test-provider"); count != 0 {
+		t.Errorf("CountItems() = %d, want 0 after a failed write", count)
+	}
+}
+
+func TestJSONRepository_CountItems(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "count-db.json")
+	ctx := context.Background()
+
+	repo := NewJSONRepository(dbPath)
+	if err := repo.SaveMany(ctx, []core.Item{
+		{ID: "1", Provider: "test-provider"},
+		{ID: "2", Provider: "test-provider"},
+		{ID: "1", Provider: "other-provider"},
+	}); err != nil {
+		t.Fatalf("SaveMany() failed: %v", err)
+	}
+
+	tests := map[string]int{"test-provider": 2, "other-provider": 1, "unknown-provider": 0}
+	for provider, want := range tests {
+		if got, err := repo.CountItems(ctx, provider); err != nil || got != want {
+			t.Errorf("CountItems(%q) = %d, %v; want %d", provider, got, err, want)
+		}
+	}
 }
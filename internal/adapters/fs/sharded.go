@@ -0,0 +1,120 @@
+package fs
+
+import (
+	"context"
+	"time"
+
+	"github.com/maximerauch/go-classifieds-watcher/internal/core"
+)
+
+// ShardedRepository stores the items of each provider in its own JSON file,
+// the layout used before a single repository served every provider.
+type ShardedRepository struct {
+	shards   map[string]*JSONRepository // Keyed by provider name
+	fallback *JSONRepository            // Providers without a dedicated file
+}
+
+// NewShardedRepository opens one file per provider, keyed by provider name in paths.
+// Other providers share the fallback file. Providers configured with the same path share its repository.
+func NewShardedRepository(paths map[string]string, fallback string) *ShardedRepository {
+	byPath := make(map[string]*JSONRepository)
+	open := func(path string) *JSONRepository {
+		if repo, ok := byPath[path]; ok {
+			return repo
+		}
+		repo := NewJSONRepository(path)
+		byPath[path] = repo
+		return repo
+	}
+
+	r := &ShardedRepository{shards: make(map[string]*JSONRepository, len(paths))}
+	for provider, path := range paths {
+		r.shards[provider] = open(path)
+	}
+	r.fallback = open(fallback)
+	return r
+}
+
+func (r *ShardedRepository) shard(provider string) *JSONRepository {
+	if repo, ok := r.shards[provider]; ok {
+		return repo
+	}
+	return r.fallback
+}
+
+func (r *ShardedRepository) Exists(ctx context.Context, key core.ItemKey) (bool, error) {
+	return r.shard(key.Provider).Exists(ctx, key)
+}
+
+// CountItems returns how many items of a provider are stored.
+func (r *ShardedRepository) CountItems(ctx context.Context, provider string) (int, error) {
+	return r.shard(provider).CountItems(ctx, provider)
+}
+
+// Get returns the stored snapshot of an item.
+func (r *ShardedRepository) Get(ctx context.Context, key core.ItemKey) (core.Item, bool, error) {
+	return r.shard(key.Provider).Get(ctx, key)
+}
+
+func (r *ShardedRepository) Save(ctx context.Context, item core.Item) error {
+	return r.shard(item.Provider).Save(ctx, item)
+}
+
+// ExistsMany checks all keys at once, with a single lookup per file.
+func (r *ShardedRepository) ExistsMany(ctx context.Context, keys []core.ItemKey) (map[core.ItemKey]bool, error) {
+	exists := make(map[core.ItemKey]bool, len(keys))
+	for repo, keys := range r.groupKeys(keys) {
+		found, err := repo.ExistsMany(ctx, keys)
+		if err != nil {
+			return nil, err
+		}
+		for key, ok := range found {
+			exists[key] = ok
+		}
+	}
+	return exists, nil
+}
+
+// GetMany returns the snapshots of all known keys at once, with a single lookup per file.
+func (r *ShardedRepository) GetMany(ctx context.Context, keys []core.ItemKey) (map[core.ItemKey]core.Item, error) {
+	snapshots := make(map[core.ItemKey]core.Item, len(keys))
+	for repo, keys := range r.groupKeys(keys) {
+		found, err := repo.GetMany(ctx, keys)
+		if err != nil {
+			return nil, err
+		}
+		for key, item := range found {
+			snapshots[key] = item
+		}
+	}
+	return snapshots, nil
+}
+
+// SaveMany stores all items with a single write per file.
+func (r *ShardedRepository) SaveMany(ctx context.Context, items []core.Item) error {
+	groups := make(map[*JSONRepository][]core.Item)
+	for _, item := range items {
+		repo := r.shard(item.Provider)
+		groups[repo] = append(groups[repo], item)
+	}
+	for repo, items := range groups {
+		if err := repo.SaveMany(ctx, items); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reconcile records the items observed by a complete scan of a provider.
+func (r *ShardedRepository) Reconcile(ctx context.Context, provider string, seen []core.ItemKey, threshold int, at time.Time) ([]core.Item, error) {
+	return r.shard(provider).Reconcile(ctx, provider, seen, threshold, at)
+}
+
+func (r *ShardedRepository) groupKeys(keys []core.ItemKey) map[*JSONRepository][]core.ItemKey {
+	groups := make(map[*JSONRepository][]core.ItemKey)
+	for _, key := range keys {
+		repo := r.shard(key.Provider)
+		groups[repo] = append(groups[repo], key)
+	}
+	return groups
+}
@@ -0,0 +1,68 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/maximerauch/go-classifieds-watcher/internal/core"
+)
+
+func TestShardedRepository(t *testing.T) {
+	tmpDir := t.TempDir()
+	paths := map[string]string{"asi67": filepath.Join(tmpDir, "asi67.json")}
+	fallback := filepath.Join(tmpDir, "seen.json")
+	ctx := context.Background()
+
+	flat := core.Item{ID: "1", Provider: "asi67", Title: "T2", Url: "https://test.com/1"}
+	dog := core.Item{ID: "1", Provider: "rememberme", Title: "Rex", Url: "https://test.com/rex"}
+
+	repo := NewShardedRepository(paths, fallback)
+	if err := repo.SaveMany(ctx, []core.Item{flat, dog}); err != nil {
+		t.Fatalf("SaveMany() returned an unexpected error: %v", err)
+	}
+
+	// Scenario 1: Each provider is written to its own file
+	t.Run("Items are stored in the file of their provider", func(t *testing.T) {
+		if count, _ := NewJSONRepository(paths["asi67"]).CountItems(ctx, "asi67"); count != 1 {
+			t.Errorf("asi67 file holds %d items, want 1", count)
+		}
+		if count, _ := NewJSONRepository(fallback).CountItems(ctx, "rememberme"); count != 1 {
+			t.Errorf("Fallback file holds %d items, want 1", count)
+		}
+	})
+
+	// Scenario 2: Lookups are routed to the right file, even for identical IDs
+	t.Run("Lookups across files", func(t *testing.T) {
+		reloaded := NewShardedRepository(paths, fallback)
+		exists, err := reloaded.ExistsMany(ctx, []core.ItemKey{flat.Key(), dog.Key(), {Provider: "asi67", ID: "2"}})
+		if err != nil {
+			t.Fatalf("ExistsMany() returned an unexpected error: %v", err)
+		}
+		if !exists[flat.Key()] || !exists[dog.Key()] || len(exists) != 2 {
+			t.Errorf("ExistsMany() = %v, want both saved items only", exists)
+		}
+
+		snapshot, ok, _ := reloaded.Get(ctx, dog.Key())
+		if !ok || snapshot.Title != "Rex" {
+			t.Errorf("Get() = %v, %v; want the saved dog", snapshot, ok)
+		}
+	})
+
+	// Scenario 3: Legacy per-provider files hold unscoped entries
+	t.Run("Legacy file of a provider", func(t *testing.T) {
+		legacyPath := filepath.Join(tmpDir, "legacy.json")
+		if err := os.WriteFile(legacyPath, []byte(`{"42": {"ID": "42", "Title": "Old"}}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		legacy := NewShardedRepository(map[string]string{"rememberme": legacyPath}, fallback)
+		if exists, _ := legacy.Exists(ctx, core.ItemKey{Provider: "rememberme", ID: "42"}); !exists {
+			t.Error("Unscoped entries of a provider file should belong to that provider")
+		}
+		if exists, _ := legacy.Exists(ctx, core.ItemKey{Provider: "asi67", ID: "42"}); exists {
+			t.Error("Unscoped entries of a provider file should not leak to other providers")
+		}
+	})
+}
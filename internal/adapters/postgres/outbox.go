@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/maximerauch/go-classifieds-watcher/internal/core"
+)
+
+// Outbox stores notification deliveries next to the seen items.
+type Outbox struct {
+	db *sql.DB
+}
+
+// NewOutbox shares the connection of the repository, whose migrations create the outbox table.
+func NewOutbox(repo *Repository) *Outbox {
+	return &Outbox{db: repo.db}
+}
+
+// payload holds the event content of a delivery.
+type payload struct {
+	Item     core.Item
+	Previous core.Item             `json:",omitempty"`
+	Changes  []core.FieldChange    `json:",omitempty"`
+	Summary  core.BootstrapSummary `json:",omitzero"`
+}
+
+func (o *Outbox) Enqueue(ctx context.Context, deliveries []core.Delivery) error {
+	tx, err := o.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }() // No-op once committed
+
+	query := `INSERT INTO notification_outbox
+		(id, channel, kind, provider, item_id, payload, status, attempts, next_attempt_at, last_error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (id) DO NOTHING`
+
+	for _, d := range deliveries {
+		data, err := json.Marshal(payload{Item: d.Item, Previous: d.Previous, Changes: d.Changes, Summary: d.Summary})
+		if err != nil {
+			return fmt.Errorf("payload marshal error: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, query,
+			d.ID, d.Channel, d.Kind, d.Item.Provider, d.Item.ID, data,
+			d.Status, d.Attempts, d.NextAttemptAt, d.LastError,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to enqueue %s: %w", d.ID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (o *Outbox) Due(ctx context.Context, now time.Time) ([]core.Delivery, error) {
+	query := `SELECT id, channel, kind, payload, status, attempts, next_attempt_at, last_error
+		FROM notification_outbox
+		WHERE status = $1 AND next_attempt_at <= $2
+		ORDER BY created_at`
+
+	rows, err := o.db.QueryContext(ctx, query, core.DeliveryPending, now)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var deliveries []core.Delivery
+	for rows.Next() {
+		var (
+			d    core.Delivery
+			data []byte
+			p    payload
+		)
+		if err := rows.Scan(&d.ID, &d.Channel, &d.Kind, &data, &d.Status, &d.Attempts, &d.NextAttemptAt, &d.LastError); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, fmt.Errorf("corrupt payload for %s: %w", d.ID, err)
+		}
+		d.Item, d.Previous, d.Changes, d.Summary = p.Item, p.Previous, p.Changes, p.Summary
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+func (o *Outbox) Update(ctx context.Context, d core.Delivery) error {
+	query := `UPDATE notification_outbox
+		SET status = $2, attempts = $3, next_attempt_at = $4, last_error = $5, completed_at = $6, updated_at = NOW()
+		WHERE id = $1`
+	completedAt := sql.NullTime{Time: d.CompletedAt, Valid: !d.CompletedAt.IsZero()}
+	_, err := o.db.ExecContext(ctx, query, d.ID, d.Status, d.Attempts, d.NextAttemptAt, d.LastError, completedAt)
+	return err
+}
+
+// Prune deletes the sent and dead-lettered deliveries completed before cutoff.
+func (o *Outbox) Prune(ctx context.Context, cutoff time.Time) (int, error) {
+	query := `DELETE FROM notification_outbox WHERE status <> $1 AND completed_at < $2`
+	result, err := o.db.ExecContext(ctx, query, core.DeliveryPending, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	pruned, err := result.RowsAffected()
+	return int(pruned), err
+}
@@ -3,14 +3,71 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/url"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/maximerauch/go-classifieds-watcher/internal/core"
 )
 
+// migrations are applied in order, each one exactly once.
+// Never edit a released entry: append a new one instead.
+var migrations = []string{
+	// 1: Initial schema
+	`CREATE TABLE IF NOT EXISTS seen_items (
+		id TEXT PRIMARY KEY,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);`,
+
+	// 2: Scope item identity by provider.
+	// Rows written before this migration all come from remember-me-france,
+	// the only provider wired in the daemon at the time.
+	`ALTER TABLE seen_items ADD COLUMN provider TEXT NOT NULL DEFAULT 'remember-me-france';
+	ALTER TABLE seen_items ALTER COLUMN provider DROP DEFAULT;
+	ALTER TABLE seen_items DROP CONSTRAINT seen_items_pkey;
+	ALTER TABLE seen_items ADD PRIMARY KEY (provider, id);`,
+
+	// 3: Store the last seen snapshot of each item to detect listing updates.
+	// Existing rows keep a NULL snapshot until the item is fetched again.
+	`ALTER TABLE seen_items ADD COLUMN snapshot JSONB;
+	ALTER TABLE seen_items ADD COLUMN updated_at TIMESTAMP;`,
+
+	// 4: Track presence to detect listings that disappeared
+	`ALTER TABLE seen_items ADD COLUMN last_seen TIMESTAMP;
+	ALTER TABLE seen_items ADD COLUMN missing_count INT NOT NULL DEFAULT 0;
+	ALTER TABLE seen_items ADD COLUMN removed_at TIMESTAMP;`,
+
+	// 5: Notification outbox, one row per item event and channel
+	`CREATE TABLE notification_outbox (
+		id TEXT PRIMARY KEY,
+		channel TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		provider TEXT NOT NULL,
+		item_id TEXT NOT NULL,
+		payload JSONB NOT NULL,
+		status TEXT NOT NULL,
+		attempts INT NOT NULL DEFAULT 0,
+		next_attempt_at TIMESTAMP NOT NULL,
+		last_error TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX notification_outbox_due ON notification_outbox (status, next_attempt_at);`,
+
+	// 6: Keep the offset of next attempts, as quiet hours postpone deliveries in their own time zone.
+	// Stored attempts lost theirs and are read as UTC, the zone of the daemon containers.
+	`ALTER TABLE notification_outbox ALTER COLUMN next_attempt_at TYPE TIMESTAMPTZ USING next_attempt_at AT TIME ZONE 'UTC';`,
+
+	// 7: Date completed deliveries to prune them after the retention period.
+	// Those completed before this migration are dated by their last update.
+	`ALTER TABLE notification_outbox ADD COLUMN completed_at TIMESTAMPTZ;
+	UPDATE notification_outbox SET completed_at = updated_at AT TIME ZONE 'UTC' WHERE status <> 'pending';
+	CREATE INDEX notification_outbox_completed ON notification_outbox (completed_at) WHERE status <> 'pending';`,
+}
+
 type Repository struct {
 	db *sql.DB
 }
@@ -19,6 +76,11 @@ func NewRepository(databaseURL string) (*Repository, error) {
 	// Parse URl to clean incompatible parameters
 	u, err := url.Parse(databaseURL)
 	if err != nil {
+		// The parse error quotes the whole URL, password included
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
 		return nil, fmt.Errorf("invalid database url: %w", err)
 	}
 
@@ -36,23 +98,56 @@ func NewRepository(databaseURL string) (*Repository, error) {
 		return nil, fmt.Errorf("database unreachable: %w", err)
 	}
 
-	query := `CREATE TABLE IF NOT EXISTS seen_items (
-		id TEXT PRIMARY KEY,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);`
-
-	if _, err := db.Exec(query); err != nil {
+	if err := migrate(db); err != nil {
 		return nil, fmt.Errorf("migration failed: %w", err)
 	}
 
 	return &Repository{db: db}, nil
 }
 
-func (r *Repository) Exists(ctx context.Context, id string) (bool, error) {
+// migrate brings the schema up to date, recording applied versions in schema_migrations.
+func migrate(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT PRIMARY KEY,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);`
+	if _, err := db.Exec(query); err != nil {
+		return err
+	}
+
+	var current int
+	if err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current); err != nil {
+		return err
+	}
+
+	for i := current; i < len(migrations); i++ {
+		version := i + 1
+
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("version %d: %w", version, err)
+		}
+		if _, err := tx.Exec("INSERT INTO schema_migrations (version) VALUES ($1)", version); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("version %d: %w", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("version %d: %w", version, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Repository) Exists(ctx context.Context, key core.ItemKey) (bool, error) {
 	var exists int
-	query := "SELECT 1 FROM seen_items WHERE id = $1"
+	query := "SELECT 1 FROM seen_items WHERE provider = $1 AND id = $2"
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&exists)
+	err := r.db.QueryRowContext(ctx, query, key.Provider, key.ID).Scan(&exists)
 	if errors.Is(err, sql.ErrNoRows) {
 		return false, nil
 	}
@@ -62,8 +157,215 @@ func (r *Repository) Exists(ctx context.Context, id string) (bool, error) {
 	return true, nil
 }
 
+// CountItems returns how many items of a provider are stored, removed ones included.
+func (r *Repository) CountItems(ctx context.Context, provider string) (int, error) {
+	var count int
+	query := "SELECT COUNT(*) FROM seen_items WHERE provider = $1"
+
+	if err := r.db.QueryRowContext(ctx, query, provider).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Get returns the stored snapshot of an item.
+// Rows saved before snapshots were introduced are reported as known with an empty item.
+func (r *Repository) Get(ctx context.Context, key core.ItemKey) (core.Item, bool, error) {
+	var snapshot []byte
+	query := "SELECT snapshot FROM seen_items WHERE provider = $1 AND id = $2"
+
+	err := r.db.QueryRowContext(ctx, query, key.Provider, key.ID).Scan(&snapshot)
+	if errors.Is(err, sql.ErrNoRows) {
+		return core.Item{}, false, nil
+	}
+	if err != nil {
+		return core.Item{}, false, err
+	}
+
+	var item core.Item
+	if snapshot != nil {
+		if err := json.Unmarshal(snapshot, &item); err != nil {
+			return core.Item{}, true, fmt.Errorf("corrupt snapshot for %s: %w", key, err)
+		}
+	}
+	return item, true, nil
+}
+
+// Save inserts the item or refreshes the snapshot of an already known one.
 func (r *Repository) Save(ctx context.Context, item core.Item) error {
-	query := "INSERT INTO seen_items (id) VALUES ($1) ON CONFLICT (id) DO NOTHING"
-	_, err := r.db.ExecContext(ctx, query, item.ID)
+	snapshot, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("snapshot marshal error: %w", err)
+	}
+
+	query := `INSERT INTO seen_items (provider, id, snapshot, updated_at) VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (provider, id) DO UPDATE SET snapshot = EXCLUDED.snapshot, updated_at = EXCLUDED.updated_at`
+	_, err = r.db.ExecContext(ctx, query, item.Provider, item.ID, snapshot)
+	return err
+}
+
+// ExistsMany checks all keys in a single query.
+func (r *Repository) ExistsMany(ctx context.Context, keys []core.ItemKey) (map[core.ItemKey]bool, error) {
+	providers, ids := splitKeys(keys)
+	query := `SELECT provider, id FROM seen_items
+		WHERE (provider, id) IN (SELECT * FROM unnest($1::text[], $2::text[]))`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(providers), pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	exists := make(map[core.ItemKey]bool, len(keys))
+	for rows.Next() {
+		var key core.ItemKey
+		if err := rows.Scan(&key.Provider, &key.ID); err != nil {
+			return nil, err
+		}
+		exists[key] = true
+	}
+	return exists, rows.Err()
+}
+
+// GetMany reads the snapshots of all keys in a single query.
+func (r *Repository) GetMany(ctx context.Context, keys []core.ItemKey) (map[core.ItemKey]core.Item, error) {
+	providers, ids := splitKeys(keys)
+	query := `SELECT provider, id, snapshot FROM seen_items
+		WHERE (provider, id) IN (SELECT * FROM unnest($1::text[], $2::text[]))`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(providers), pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	snapshots := make(map[core.ItemKey]core.Item, len(keys))
+	for rows.Next() {
+		var (
+			key      core.ItemKey
+			snapshot []byte
+			item     core.Item
+		)
+		if err := rows.Scan(&key.Provider, &key.ID, &snapshot); err != nil {
+			return nil, err
+		}
+		if snapshot != nil {
+			if err := json.Unmarshal(snapshot, &item); err != nil {
+				return nil, fmt.Errorf("corrupt snapshot for %s: %w", key, err)
+			}
+		}
+		snapshots[key] = item
+	}
+	return snapshots, rows.Err()
+}
+
+// SaveMany upserts all items in a single statement, so they are all saved or none is.
+func (r *Repository) SaveMany(ctx context.Context, items []core.Item) error {
+	// A statement cannot upsert the same row twice: keep the last snapshot of each key
+	latest := make(map[core.ItemKey]core.Item, len(items))
+	for _, item := range items {
+		latest[item.Key()] = item
+	}
+
+	providers := make([]string, 0, len(latest))
+	ids := make([]string, 0, len(latest))
+	snapshots := make([]string, 0, len(latest))
+	for key, item := range latest {
+		snapshot, err := json.Marshal(item)
+		if err != nil {
+			return fmt.Errorf("snapshot marshal error: %w", err)
+		}
+		providers = append(providers, key.Provider)
+		ids = append(ids, key.ID)
+		snapshots = append(snapshots, string(snapshot))
+	}
+
+	query := `INSERT INTO seen_items (provider, id, snapshot, updated_at)
+		SELECT provider, id, snapshot::jsonb, NOW()
+		FROM unnest($1::text[], $2::text[], $3::text[]) AS t(provider, id, snapshot)
+		ON CONFLICT (provider, id) DO UPDATE SET snapshot = EXCLUDED.snapshot, updated_at = EXCLUDED.updated_at`
+	_, err := r.db.ExecContext(ctx, query, pq.Array(providers), pq.Array(ids), pq.Array(snapshots))
 	return err
 }
+
+func splitKeys(keys []core.ItemKey) ([]string, []string) {
+	providers := make([]string, 0, len(keys))
+	ids := make([]string, 0, len(keys))
+	for _, key := range keys {
+		providers = append(providers, key.Provider)
+		ids = append(ids, key.ID)
+	}
+	return providers, ids
+}
+
+// Reconcile records the items observed by a complete scan of a provider in a single transaction.
+func (r *Repository) Reconcile(ctx context.Context, provider string, seen []core.ItemKey, threshold int, at time.Time) ([]core.Item, error) {
+	ids := make([]string, 0, len(seen))
+	for _, key := range seen {
+		ids = append(ids, key.ID)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }() // No-op once committed
+
+	// Observed items: reset the miss counter and restore them if they had been removed
+	query := `UPDATE seen_items SET last_seen = $3, missing_count = 0, removed_at = NULL
+		WHERE provider = $1 AND id = ANY($2)`
+	if _, err := tx.ExecContext(ctx, query, provider, pq.Array(ids), at); err != nil {
+		return nil, fmt.Errorf("failed to mark seen items: %w", err)
+	}
+
+	// Missing items: one more miss
+	query = `UPDATE seen_items SET missing_count = missing_count + 1
+		WHERE provider = $1 AND removed_at IS NULL AND NOT (id = ANY($2))`
+	if _, err := tx.ExecContext(ctx, query, provider, pq.Array(ids)); err != nil {
+		return nil, fmt.Errorf("failed to count missing items: %w", err)
+	}
+
+	// Items over the threshold are removed
+	query = `UPDATE seen_items SET removed_at = $2
+		WHERE provider = $1 AND removed_at IS NULL AND missing_count >= $3
+		RETURNING id, snapshot`
+	rows, err := tx.QueryContext(ctx, query, provider, at, threshold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark removed items: %w", err)
+	}
+
+	removed, err := scanItems(rows, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// scanItems reads (id, snapshot) rows. Rows without snapshot only carry their identity.
+func scanItems(rows *sql.Rows, provider string) ([]core.Item, error) {
+	defer func() { _ = rows.Close() }()
+
+	var items []core.Item
+	for rows.Next() {
+		var (
+			id       string
+			snapshot []byte
+		)
+		if err := rows.Scan(&id, &snapshot); err != nil {
+			return nil, err
+		}
+
+		item := core.Item{ID: id, Provider: provider}
+		if snapshot != nil {
+			if err := json.Unmarshal(snapshot, &item); err != nil {
+				return nil, fmt.Errorf("corrupt snapshot for %s/%s: %w", provider, id, err)
+			}
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
@@ -5,7 +5,9 @@ package postgres
 import (
 	"context"
 	"os"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/maximerauch/go-classifieds-watcher/internal/core"
 )
@@ -33,22 +35,24 @@ func TestRepository_Integration(t *testing.T) {
 
 	// Clean up the table to ensure a pristine state before running assertions.
 	// Since NewRepository runs migrations, the table is guaranteed to exist.
-	if _, err := repo.db.Exec("TRUNCATE TABLE seen_items"); err != nil {
+	if _, err := repo.db.Exec("TRUNCATE TABLE seen_items, notification_outbox"); err != nil {
 		t.Fatalf("Failed to truncate table: %v", err)
 	}
 
 	ctx := context.Background()
 	itemID := "test-item-123"
 	item := core.Item{
-		ID:    itemID,
-		Title: "Integration Test Item",
+		ID:       itemID,
+		Provider: "integration",
+		Title:    "Integration Test Item",
 	}
+	key := item.Key()
 
 	// TEST SCENARIOS
 
 	// Scenario A: Check for an item that hasn't been saved yet.
 	t.Run("Returns false when item does not exist", func(t *testing.T) {
-		exists, err := repo.Exists(ctx, itemID)
+		exists, err := repo.Exists(ctx, key)
 		if err != nil {
 			t.Fatalf("Exists() failed unexpectedly: %v", err)
 		}
@@ -67,7 +71,7 @@ func TestRepository_Integration(t *testing.T) {
 
 	// Scenario C: Verify the item now exists.
 	t.Run("Returns true when item exists", func(t *testing.T) {
-		exists, err := repo.Exists(ctx, itemID)
+		exists, err := repo.Exists(ctx, key)
 		if err != nil {
 			t.Fatalf("Exists() failed unexpectedly: %v", err)
 		}
@@ -86,7 +90,7 @@ func TestRepository_Integration(t *testing.T) {
 
 		// Verify that we still have only 1 row (no duplicates)
 		var count int
-		err = repo.db.QueryRow("SELECT COUNT(*) FROM seen_items WHERE id = $1", itemID).Scan(&count)
+		err = repo.db.QueryRow("SELECT COUNT(*) FROM seen_items WHERE provider = $1 AND id = $2", key.Provider, key.ID).Scan(&count)
 		if err != nil {
 			t.Fatalf("Count query failed: %v", err)
 		}
@@ -94,4 +98,259 @@ func TestRepository_Integration(t *testing.T) {
 			t.Errorf("Expected 1 record, found %d", count)
 		}
 	})
+
+	// Scenario E: Provider scoping.
+	// The same raw ID coming from another provider is a different item.
+	t.Run("Scopes identity by provider", func(t *testing.T) {
+		other := core.ItemKey{Provider: "another-provider", ID: itemID}
+
+		exists, err := repo.Exists(ctx, other)
+		if err != nil {
+			t.Fatalf("Exists() failed unexpectedly: %v", err)
+		}
+		if exists {
+			t.Error("Expected Exists() to return false for another provider, got true")
+		}
+	})
+
+	// Scenario F: Snapshots.
+	// Saving a known item again refreshes its stored snapshot.
+	t.Run("Refreshes the stored snapshot", func(t *testing.T) {
+		updated := item
+		updated.Price = 850
+		if err := repo.Save(ctx, updated); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+
+		snapshot, known, err := repo.Get(ctx, key)
+		if err != nil {
+			t.Fatalf("Get() failed unexpectedly: %v", err)
+		}
+		if !known {
+			t.Fatal("Expected Get() to report the item as known")
+		}
+		if snapshot.Price != 850 {
+			t.Errorf("Snapshot price = %.2f, want 850", snapshot.Price)
+		}
+	})
+
+	// Scenario G: Presence tracking.
+	// An item missing from consecutive complete scans is reported once as removed.
+	t.Run("Marks missing items as removed after threshold", func(t *testing.T) {
+		now := time.Now()
+
+		removed, err := repo.Reconcile(ctx, key.Provider, []core.ItemKey{{Provider: key.Provider, ID: "other"}}, 2, now)
+		if err != nil {
+			t.Fatalf("Reconcile() failed: %v", err)
+		}
+		if len(removed) != 0 {
+			t.Fatalf("Expected no removal after one miss, got %d", len(removed))
+		}
+
+		removed, err = repo.Reconcile(ctx, key.Provider, []core.ItemKey{{Provider: key.Provider, ID: "other"}}, 2, now)
+		if err != nil {
+			t.Fatalf("Reconcile() failed: %v", err)
+		}
+		if len(removed) != 1 || removed[0].ID != itemID {
+			t.Fatalf("Expected %s to be removed, got %v", itemID, removed)
+		}
+
+		// Seen again: restored and not reported twice
+		removed, err = repo.Reconcile(ctx, key.Provider, []core.ItemKey{key}, 2, now)
+		if err != nil {
+			t.Fatalf("Reconcile() failed: %v", err)
+		}
+		if len(removed) != 0 {
+			t.Errorf("Expected no removal once the item is seen again, got %d", len(removed))
+		}
+	})
+
+	// Scenario H: Outbox.
+	// Deliveries are deduplicated by ID and come back once due.
+	t.Run("Stores deliveries until they are sent", func(t *testing.T) {
+		outbox := NewOutbox(repo)
+		now := time.Now()
+
+		delivery := core.Delivery{
+			ID:            "email|new|" + key.String(),
+			Channel:       "email",
+			Kind:          core.DeliveryNew,
+			Item:          item,
+			Status:        core.DeliveryPending,
+			NextAttemptAt: now,
+		}
+
+		// Enqueued twice, stored once
+		for i := 0; i < 2; i++ {
+			if err := outbox.Enqueue(ctx, []core.Delivery{delivery}); err != nil {
+				t.Fatalf("Enqueue() failed: %v", err)
+			}
+		}
+
+		due, err := outbox.Due(ctx, now)
+		if err != nil {
+			t.Fatalf("Due() failed: %v", err)
+		}
+		if len(due) != 1 || due[0].Item.Title != item.Title {
+			t.Fatalf("Expected 1 due delivery carrying the item, got %v", due)
+		}
+
+		due[0].Status = core.DeliverySent
+		due[0].Attempts = 1
+		if err := outbox.Update(ctx, due[0]); err != nil {
+			t.Fatalf("Update() failed: %v", err)
+		}
+
+		due, err = outbox.Due(ctx, now)
+		if err != nil {
+			t.Fatalf("Due() failed: %v", err)
+		}
+		if len(due) != 0 {
+			t.Errorf("Expected no due delivery once sent, got %d", len(due))
+		}
+	})
+
+	// Scenario H2: Quiet hours.
+	// A delivery postponed in the time zone of quiet hours is due at that instant, whatever the zone of the caller.
+	t.Run("Compares next attempts across time zones", func(t *testing.T) {
+		paris, err := time.LoadLocation("Europe/Paris")
+		if err != nil {
+			t.Skipf("Time zone database unavailable: %v", err)
+		}
+		outbox := NewOutbox(repo)
+		now := time.Now().UTC()
+
+		// Quiet from an hour ago to two hours from now, Paris time
+		local := now.In(paris)
+		window := local.Add(-time.Hour).Format("15:04") + "-" + local.Add(2*time.Hour).Format("15:04")
+		quiet, err := core.ParseQuietHours(window, "Europe/Paris")
+		if err != nil {
+			t.Fatalf("ParseQuietHours() failed: %v", err)
+		}
+		item := core.Item{ID: "quiet", Provider: "integration", Title: "Held"}
+		held := core.Delivery{
+			ID:            "email|new|quiet",
+			Channel:       "email",
+			Kind:          core.DeliveryNew,
+			Item:          item,
+			Status:        core.DeliveryPending,
+			NextAttemptAt: quiet.DeliverAt(item, now),
+		}
+		if held.NextAttemptAt.Location().String() != "Europe/Paris" || !held.NextAttemptAt.After(now) {
+			t.Fatalf("DeliverAt() = %v, want the end of the window in Europe/Paris", held.NextAttemptAt)
+		}
+		if err := outbox.Enqueue(ctx, []core.Delivery{held}); err != nil {
+			t.Fatalf("Enqueue() failed: %v", err)
+		}
+
+		for _, at := range []time.Time{now, held.NextAttemptAt.Add(-time.Minute).UTC()} {
+			due, err := outbox.Due(ctx, at)
+			if err != nil {
+				t.Fatalf("Due() failed: %v", err)
+			}
+			if slices.ContainsFunc(due, func(d core.Delivery) bool { return d.ID == held.ID }) {
+				t.Errorf("Due(%v) returned the delivery held until %v", at, held.NextAttemptAt)
+			}
+		}
+
+		due, err := outbox.Due(ctx, held.NextAttemptAt.UTC())
+		if err != nil {
+			t.Fatalf("Due() failed: %v", err)
+		}
+		if !slices.ContainsFunc(due, func(d core.Delivery) bool { return d.ID == held.ID }) {
+			t.Errorf("Due(%v) = %v, want the delivery held until then", held.NextAttemptAt.UTC(), due)
+		}
+	})
+
+	// Scenario H3: Retention.
+	// Completed deliveries are deleted once old enough, pending ones are kept.
+	t.Run("Prunes completed deliveries", func(t *testing.T) {
+		outbox := NewOutbox(repo)
+		now := time.Now()
+
+		base := core.Delivery{Channel: "email", Kind: core.DeliveryNew, Item: item, Status: core.DeliveryPending, NextAttemptAt: now.Add(time.Hour)}
+		old, recent, waiting := base, base, base
+		old.ID, recent.ID, waiting.ID = "prune|old", "prune|recent", "prune|waiting"
+		if err := outbox.Enqueue(ctx, []core.Delivery{old, recent, waiting}); err != nil {
+			t.Fatalf("Enqueue() failed: %v", err)
+		}
+		old.Status, old.CompletedAt = core.DeliverySent, now.Add(-48*time.Hour)
+		recent.Status, recent.CompletedAt = core.DeliveryFailed, now
+		for _, d := range []core.Delivery{old, recent} {
+			if err := outbox.Update(ctx, d); err != nil {
+				t.Fatalf("Update() failed: %v", err)
+			}
+		}
+
+		pruned, err := outbox.Prune(ctx, now.Add(-24*time.Hour))
+		if err != nil {
+			t.Fatalf("Prune() failed: %v", err)
+		}
+		if pruned != 1 {
+			t.Errorf("Prune() = %d, want the old sent delivery only", pruned)
+		}
+
+		var ids []string
+		rows, err := repo.db.Query("SELECT id FROM notification_outbox WHERE id LIKE 'prune|%' ORDER BY id")
+		if err != nil {
+			t.Fatalf("Failed to list deliveries: %v", err)
+		}
+		defer func() { _ = rows.Close() }()
+		for rows.Next() {
+			var id string
+			_ = rows.Scan(&id)
+			ids = append(ids, id)
+		}
+		if len(ids) != 2 || ids[0] != "prune|recent" || ids[1] != "prune|waiting" {
+			t.Errorf("Deliveries kept = %v, want the recent and pending ones", ids)
+		}
+	})
+
+	// Scenario I: Batch operations.
+	// Many items are saved and checked in a single round-trip.
+	t.Run("Saves and reads items in batch", func(t *testing.T) {
+		batch := []core.Item{
+			{ID: "batch-1", Provider: "integration", Title: "First"},
+			{ID: "batch-2", Provider: "integration", Title: "Second"},
+			{ID: "batch-1", Provider: "integration", Title: "First, updated"}, // Same key twice
+		}
+		if err := repo.SaveMany(ctx, batch); err != nil {
+			t.Fatalf("SaveMany() failed: %v", err)
+		}
+
+		keys := []core.ItemKey{batch[0].Key(), batch[1].Key(), {Provider: "integration", ID: "unknown"}}
+
+		exists, err := repo.ExistsMany(ctx, keys)
+		if err != nil {
+			t.Fatalf("ExistsMany() failed: %v", err)
+		}
+		if !exists[keys[0]] || !exists[keys[1]] || exists[keys[2]] {
+			t.Errorf("ExistsMany() = %v, want the two saved keys only", exists)
+		}
+
+		snapshots, err := repo.GetMany(ctx, keys)
+		if err != nil {
+			t.Fatalf("GetMany() failed: %v", err)
+		}
+		if len(snapshots) != 2 || snapshots[keys[0]].Title != "First, updated" {
+			t.Errorf("GetMany() = %v, want the latest snapshots of the two saved keys", snapshots)
+		}
+	})
+
+	// Scenario J: Counting items of a provider.
+	// A provider without stored items triggers the bootstrap mode.
+	t.Run("Counts the items of a provider", func(t *testing.T) {
+		count, err := repo.CountItems(ctx, "integration")
+		if err != nil {
+			t.Fatalf("CountItems() failed: %v", err)
+		}
+		if count == 0 {
+			t.Error("CountItems() = 0, want the items saved by previous scenarios")
+		}
+
+		count, err = repo.CountItems(ctx, "never-scanned")
+		if err != nil || count != 0 {
+			t.Errorf("CountItems() = %d, %v; want 0 for an unknown provider", count, err)
+		}
+	})
 }
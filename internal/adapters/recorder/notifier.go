@@ -0,0 +1,82 @@
+package recorder
+
+import (
+	"context"
+	"sync"
+
+	"github.com/maximerauch/go-classifieds-watcher/internal/core"
+)
+
+// Notification is a recorded notification.
+type Notification struct {
+	Kind core.DeliveryKind
+	Item core.Item
+}
+
+// Notifier records the notifications of a channel, then hands them to an optional renderer,
+// e.g. an email notifier writing its messages instead of sending them.
+type Notifier struct {
+	renderer core.Notifier // nil when notifications are only recorded
+
+	mu       sync.Mutex
+	recorded []Notification
+}
+
+func NewNotifier(renderer core.Notifier) *Notifier {
+	return &Notifier{renderer: renderer}
+}
+
+func (n *Notifier) Send(ctx context.Context, item core.Item) error {
+	n.record(core.DeliveryNew, item)
+	if n.renderer == nil {
+		return nil
+	}
+	return n.renderer.Send(ctx, item)
+}
+
+// SendBatch records new items, rendered as a digest when the renderer supports it.
+func (n *Notifier) SendBatch(ctx context.Context, items []core.Item) error {
+	for _, item := range items {
+		n.record(core.DeliveryNew, item)
+	}
+	if n.renderer == nil {
+		return nil
+	}
+	return core.NotifyBatch(ctx, n.renderer, items)
+}
+
+func (n *Notifier) SendChange(ctx context.Context, change core.ItemChange) error {
+	n.record(core.DeliveryChanged, change.Item)
+	if n.renderer == nil {
+		return nil
+	}
+	return core.NotifyChange(ctx, n.renderer, change)
+}
+
+func (n *Notifier) SendRemoval(ctx context.Context, item core.Item) error {
+	n.record(core.DeliveryRemoved, item)
+	if n.renderer == nil {
+		return nil
+	}
+	return core.NotifyRemoval(ctx, n.renderer, item)
+}
+
+func (n *Notifier) SendSummary(ctx context.Context, summary core.BootstrapSummary) error {
+	if n.renderer == nil {
+		return nil
+	}
+	return core.NotifySummary(ctx, n.renderer, summary)
+}
+
+// Recorded returns the recorded notifications, in notification order.
+func (n *Notifier) Recorded() []Notification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]Notification(nil), n.recorded...)
+}
+
+func (n *Notifier) record(kind core.DeliveryKind, item core.Item) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.recorded = append(n.recorded, Notification{Kind: kind, Item: item})
+}
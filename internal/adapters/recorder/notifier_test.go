@@ -0,0 +1,55 @@
+package recorder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maximerauch/go-classifieds-watcher/internal/core"
+)
+
+// renderer counts the notifications it renders.
+type renderer struct {
+	sent int
+}
+
+func (r *renderer) Send(ctx context.Context, item core.Item) error {
+	r.sent++
+	return nil
+}
+
+func TestNotifier(t *testing.T) {
+	ctx := context.Background()
+	first := core.Item{ID: "1", Title: "First", Url: "https://test.com/1"}
+	second := core.Item{ID: "2", Title: "Second", Url: "https://test.com/2"}
+
+	t.Run("Records and renders every notification", func(t *testing.T) {
+		r := &renderer{}
+		n := NewNotifier(r)
+
+		_ = n.SendBatch(ctx, []core.Item{first, second})
+		_ = n.SendChange(ctx, core.ItemChange{Item: first, Previous: first})
+		_ = n.SendRemoval(ctx, second)
+
+		recorded := n.Recorded()
+		if len(recorded) != 4 {
+			t.Fatalf("Recorded() = %v, want 4 notifications", recorded)
+		}
+		if recorded[2].Kind != core.DeliveryChanged || recorded[3].Kind != core.DeliveryRemoved {
+			t.Errorf("Recorded() kinds = %s, %s; want changed then removed", recorded[2].Kind, recorded[3].Kind)
+		}
+		// The renderer has no digest nor removal rendering
+		if r.sent != 3 {
+			t.Errorf("Renderer received %d notifications, want 3", r.sent)
+		}
+	})
+
+	t.Run("Records without renderer", func(t *testing.T) {
+		n := NewNotifier(nil)
+		if err := n.Send(ctx, first); err != nil {
+			t.Fatalf("Send() returned an unexpected error: %v", err)
+		}
+		if len(n.Recorded()) != 1 {
+			t.Errorf("Recorded() = %v, want 1 notification", n.Recorded())
+		}
+	})
+}
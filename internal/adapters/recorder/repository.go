@@ -0,0 +1,45 @@
+// Package recorder provides adapters recording what the watcher would persist and notify,
+// without side effects, for dry runs.
+package recorder
+
+import (
+	"context"
+	"sync"
+
+	"github.com/maximerauch/go-classifieds-watcher/internal/core"
+)
+
+// Repository is an empty in-memory repository recording the saved items.
+// Every item looks new to a run, as it would for a search turned on for the first time.
+type Repository struct {
+	mu    sync.Mutex
+	saved []core.Item
+	seen  map[core.ItemKey]bool
+}
+
+func NewRepository() *Repository {
+	return &Repository{seen: make(map[core.ItemKey]bool)}
+}
+
+// Exists reports whether an item was saved during this dry run.
+func (r *Repository) Exists(ctx context.Context, key core.ItemKey) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.seen[key], nil
+}
+
+// Save records the item instead of persisting it.
+func (r *Repository) Save(ctx context.Context, item core.Item) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.saved = append(r.saved, item)
+	r.seen[item.Key()] = true
+	return nil
+}
+
+// Saved returns the recorded items, in save order.
+func (r *Repository) Saved() []core.Item {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]core.Item(nil), r.saved...)
+}
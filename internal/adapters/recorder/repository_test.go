@@ -0,0 +1,28 @@
+package recorder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maximerauch/go-classifieds-watcher/internal/core"
+)
+
+func TestRepository(t *testing.T) {
+	repo := NewRepository()
+	item := core.Item{ID: "1", Provider: "p", Title: "T2", Url: "https://test.com/1"}
+
+	if exists, _ := repo.Exists(context.Background(), item.Key()); exists {
+		t.Error("Exists() should report every item as new before it is saved")
+	}
+
+	if err := repo.Save(context.Background(), item); err != nil {
+		t.Fatalf("Save() returned an unexpected error: %v", err)
+	}
+
+	if exists, _ := repo.Exists(context.Background(), item.Key()); !exists {
+		t.Error("Exists() should report the items saved during the dry run")
+	}
+	if saved := repo.Saved(); len(saved) != 1 || saved[0].ID != "1" {
+		t.Errorf("Saved() = %v, want the saved item", saved)
+	}
+}
@@ -3,114 +3,180 @@ package rememberme
 import (
 	"context"
 	"fmt"
+	"iter"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"path"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/maximerauch/go-classifieds-watcher/internal/core"
 )
 
 type Provider struct {
+	name      string
 	client    *http.Client
 	searchURL string
+	logger    *slog.Logger
 }
 
-func NewProvider(searchURL string) *Provider {
-	return &Provider{
+// Option customizes a Provider.
+type Option func(*Provider)
+
+// WithName names the provider, so that several searches keep their items apart.
+func WithName(name string) Option {
+	return func(p *Provider) {
+		p.name = name
+	}
+}
+
+// WithLogger reports the incidents that do not fail a fetch, discarded otherwise.
+func WithLogger(logger *slog.Logger) Option {
+	return func(p *Provider) {
+		p.logger = logger
+	}
+}
+
+func NewProvider(searchURL string, opts ...Option) *Provider {
+	p := &Provider{
+		name: "remember-me-france",
 		client: &http.Client{
 			// Increased timeout as HTML scraping is slower than JSON APIs
 			Timeout: 30 * time.Second,
 		},
 		searchURL: searchURL,
+		logger:    slog.New(slog.DiscardHandler),
+	}
+	for _, opt := range opts {
+		opt(p)
 	}
+	p.logger = p.logger.With("provider", p.name)
+	return p
 }
 
 func (p *Provider) Name() string {
-	return "remember-me-france"
+	return p.name
 }
 
+// FetchItems collects the stream of items, for callers that need them all at once.
 func (p *Provider) FetchItems(ctx context.Context) ([]core.Item, error) {
-	// Fetch Page 1 to discover total pages and initial items
-	doc, err := p.fetchDocument(ctx, p.searchURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch first page: %w", err)
-	}
+	return core.Collect(p.StreamItems(ctx))
+}
 
-	// Detect max page number from pagination
-	maxPage := 1
-	doc.Find("a.page-numbers").Each(func(i int, s *goquery.Selection) {
-		txt := strings.TrimSpace(s.Text())
-		if pageNum, err := strconv.Atoi(txt); err == nil {
-			if pageNum > maxPage {
-				maxPage = pageNum
-			}
+// pageResult carries the outcome of a page scraped in the background.
+type pageResult struct {
+	page  int
+	items []core.Item
+	err   error
+}
+
+// StreamItems yields the items page by page, as soon as each page is scraped.
+func (p *Provider) StreamItems(ctx context.Context) iter.Seq2[core.Item, error] {
+	return func(yield func(core.Item, error) bool) {
+		// Fetch Page 1 to discover total pages and initial items
+		doc, err := p.fetchDocument(ctx, p.searchURL)
+		if err != nil {
+			yield(core.Item{}, fmt.Errorf("failed to fetch first page: %w", err))
+			return
 		}
-	})
 
-	fmt.Printf("DEBUG: Found max page: %d\n", maxPage)
+		// Detect max page number from pagination
+		maxPage := 1
+		doc.Find("a.page-numbers").Each(func(i int, s *goquery.Selection) {
+			txt := strings.TrimSpace(s.Text())
+			if pageNum, err := strconv.Atoi(txt); err == nil {
+				if pageNum > maxPage {
+					maxPage = pageNum
+				}
+			}
+		})
 
-	// Extract items from page 1
-	allItems := p.extractItems(doc)
+		p.logger.Debug("starting parallel fetch", "pages", maxPage)
 
-	if maxPage <= 1 {
-		return allItems, nil
-	}
+		// Yield items from page 1
+		for _, item := range p.extractItems(doc) {
+			if !yield(item, nil) {
+				return
+			}
+		}
 
-	// Fan-Out: Scrape remaining pages in parallel
-	var (
-		wg sync.WaitGroup
-		mu sync.Mutex
-	)
+		if maxPage <= 1 {
+			return
+		}
 
-	// RATE LIMITING: Crucial to avoid IP ban.
-	// Use a buffered channel as a semaphore to limit concurrency to 5 workers.
-	sem := make(chan struct{}, 5)
+		// Stop the remaining scrapes if the consumer stops early
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
 
-	for page := 2; page <= maxPage; page++ {
-		wg.Add(1)
-		go func(pNum int) {
-			defer wg.Done()
+		// Fan-Out: Scrape remaining pages in parallel.
+		// The buffer lets every scrape complete, so no page can be silently dropped.
+		results := make(chan pageResult, maxPage-1)
+		var wg sync.WaitGroup
 
-			// Acquire token (blocks if 5 workers are already active)
-			sem <- struct{}{}
-			defer func() { <-sem }() // Release token
+		// RATE LIMITING: Crucial to avoid IP ban.
+		// Use a buffered channel as a semaphore to limit concurrency to 5 workers.
+		sem := make(chan struct{}, 5)
 
-			if ctx.Err() != nil {
-				return
-			}
+		for page := 2; page <= maxPage; page++ {
+			wg.Add(1)
+			go func(pNum int) {
+				defer wg.Done()
 
-			// Construct the paginated URL dynamically based on the initial searchURL
-			targetURL, err := p.buildPageURL(p.searchURL, pNum)
-			if err != nil {
-				fmt.Printf("⚠️ Error building URL for page %d: %v\n", pNum, err)
-				return
-			}
+				// Acquire token (blocks if 5 workers are already active)
+				sem <- struct{}{}
+				defer func() { <-sem }() // Release token
 
-			pageDoc, err := p.fetchDocument(ctx, targetURL)
-			if err != nil {
-				// Best effort: Log error but continue processing other pages
-				fmt.Printf("⚠️ Error scraping page %d: %v\n", pNum, err)
-				return
+				items, err := p.scrapePage(ctx, pNum)
+				results <- pageResult{page: pNum, items: items, err: err}
+			}(page)
+		}
+		go func() {
+			wg.Wait()
+			close(results)
+		}()
+
+		// Fan-In: Yield pages in completion order
+		for result := range results {
+			if result.err != nil {
+				// Best effort: keep the other pages, but report the scan as incomplete
+				if !yield(core.Item{}, core.PageError{Page: result.page, Err: result.err}) {
+					return
+				}
+				continue
 			}
+			for _, item := range result.items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
+}
 
-			items := p.extractItems(pageDoc)
-
-			// Thread-safe append
-			mu.Lock()
-			allItems = append(allItems, items...)
-			mu.Unlock()
-		}(page)
+// scrapePage fetches and parses one page of the search results.
+func (p *Provider) scrapePage(ctx context.Context, pageNum int) ([]core.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	wg.Wait()
+	// Construct the paginated URL dynamically based on the initial searchURL
+	targetURL, err := p.buildPageURL(p.searchURL, pageNum)
+	if err != nil {
+		return nil, fmt.Errorf("build url: %w", err)
+	}
 
-	fmt.Printf("DEBUG: Total items found: %d\n", len(allItems))
-	return allItems, nil
+	doc, err := p.fetchDocument(ctx, targetURL)
+	if err != nil {
+		return nil, err
+	}
+	return p.extractItems(doc), nil
 }
 
 // buildPageURL injects "/page/N/" into the URL path for pagination.
@@ -149,7 +215,7 @@ func (p *Provider) fetchDocument(ctx context.Context, url string) (*goquery.Docu
 	}
 	defer func() {
 		if cerr := resp.Body.Close(); cerr != nil {
-			fmt.Printf("⚠️ Error closing response body: %v\n", cerr)
+			p.logger.Warn("failed to close response body", "error", cerr)
 		}
 	}()
 
@@ -183,6 +249,16 @@ func (p *Provider) extractItems(doc *goquery.Document) []core.Item {
 		rawDesc := s.Find(".pet-content").Text()
 		description := strings.TrimSpace(strings.ReplaceAll(rawDesc, "\n", " "))
 
+		// Photo: thumbnails are lazy-loaded, the real URL then lives in data-src
+		var images []string
+		img := s.Find("img").First()
+		if src := img.AttrOr("data-src", img.AttrOr("src", "")); src != "" && !strings.HasPrefix(src, "data:") {
+			images = append(images, src)
+		}
+
+		// Urgency: WordPress exposes the "urgence" taxonomy as article classes
+		classes, _ := s.Attr("class")
+
 		if id != "" && title != "" {
 			items = append(items, core.Item{
 				ID:          id,
@@ -192,9 +268,153 @@ func (p *Provider) extractItems(doc *goquery.Document) []core.Item {
 				Currency:    "EUR",
 				PublishedAt: time.Now(),
 				Description: description,
+				Images:      images,
+				Animal:      parseAnimal(description, classes),
 			})
 		}
 	})
 
 	return items
 }
+
+var (
+	agePattern   = regexp.MustCompile(`(?i)(\d+)\s*(ans?|mois)\b`)
+	breedPattern = regexp.MustCompile(`(?i)race\s*:\s*([^,.;]+)`)
+)
+
+// Words naming the species and sex of an animal, in listings and in the slugs of the site taxonomy.
+var (
+	speciesWords = map[string]string{"chien": "dog", "chienne": "dog", "chiot": "dog", "chat": "cat", "chatte": "cat", "chaton": "cat"}
+	sexWords     = map[string]core.Sex{"mâle": core.SexMale, "male": core.SexMale, "femelle": core.SexFemale, "chienne": core.SexFemale, "chatte": core.SexFemale}
+)
+
+// urgentClass is the article class of the "urgence" taxonomy term flagging an urgent adoption,
+// other terms like "urgence-non" being no urgency.
+const urgentClass = "urgence-oui"
+
+// Words flagging an urgent adoption in a description, unless the word before negates them: "pas urgent".
+var (
+	urgentWords   = map[string]bool{"urgent": true, "urgente": true, "urgents": true, "urgentes": true}
+	negationWords = map[string]bool{"pas": true, "non": true, "plus": true}
+)
+
+// words splits text into its lowercase words, so that "achat" is no "chat".
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) })
+}
+
+// isUrgent reports whether the article classes or the description flag an urgent adoption.
+func isUrgent(description, classes string) bool {
+	if slices.Contains(strings.Fields(classes), urgentClass) {
+		return true
+	}
+	descWords := words(description)
+	for i, word := range descWords {
+		if urgentWords[word] && (i == 0 || !negationWords[descWords[i-1]]) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAnimal reads the animal attributes of a listing from its article classes, the taxonomy of the site,
+// falling back to its description. Information the shelter did not publish is left to its zero value.
+func parseAnimal(description, classes string) *core.AnimalAttributes {
+	animal := &core.AnimalAttributes{
+		Urgent: isUrgent(description, classes),
+	}
+
+	// Whole words only. The first one wins, as the listing opens with the animal
+	// it presents before mentioning the others it gets along with.
+	for _, text := range []string{classes, description} {
+		for _, word := range words(text) {
+			if species, ok := speciesWords[word]; ok && animal.Species == "" {
+				animal.Species = species
+			}
+			if sex, ok := sexWords[word]; ok && animal.Sex == core.SexUnknown {
+				animal.Sex = sex
+			}
+		}
+	}
+
+	if match := breedPattern.FindStringSubmatch(description); match != nil {
+		animal.Breed = strings.TrimSpace(match[1])
+	}
+
+	if match := agePattern.FindStringSubmatch(description); match != nil {
+		if n, err := strconv.Atoi(match[1]); err == nil {
+			if strings.EqualFold(match[2], "mois") {
+				animal.AgeMonths = n
+			} else {
+				animal.AgeMonths = n * 12
+			}
+		}
+	}
+
+	return animal
+}
+
+// Enrich completes a listing from its detail page: full description, photos, publication date
+// and the animal attributes the search results did not show.
+func (p *Provider) Enrich(ctx context.Context, item core.Item) (core.Item, error) {
+	doc, err := p.fetchDocument(ctx, item.Url)
+	if err != nil {
+		return item, fmt.Errorf("failed to fetch detail page: %w", err)
+	}
+	return enrichFromDocument(item, doc), nil
+}
+
+// enrichFromDocument reads the details of a listing from its WordPress detail page.
+func enrichFromDocument(item core.Item, doc *goquery.Document) core.Item {
+	content := doc.Find(".entry-content").First()
+	if text := strings.Join(strings.Fields(content.Text()), " "); text != "" {
+		item.FullDescription = text
+	}
+
+	// Images: the featured one first, then the gallery. They replace the search results thumbnail.
+	var images []string
+	addImage := func(src string) {
+		if src != "" && !slices.Contains(images, src) {
+			images = append(images, src)
+		}
+	}
+	addImage(doc.Find(`meta[property="og:image"]`).AttrOr("content", ""))
+	content.Find("img").Each(func(i int, s *goquery.Selection) {
+		addImage(s.AttrOr("src", ""))
+	})
+	if len(images) > 0 {
+		item.Images = images
+	}
+
+	if published, ok := doc.Find(`meta[property="article:published_time"]`).Attr("content"); ok {
+		if at, err := time.Parse(time.RFC3339, published); err == nil {
+			item.PublishedAt = at
+		}
+	}
+
+	classes := doc.Find("article").First().AttrOr("class", "")
+	item.Animal = mergeAnimal(item.Animal, parseAnimal(item.FullDescription, classes))
+	return item
+}
+
+// mergeAnimal completes the attributes read from the search results with the detail page ones.
+func mergeAnimal(listed, detail *core.AnimalAttributes) *core.AnimalAttributes {
+	if listed == nil {
+		return detail
+	}
+	merged := *listed
+	if merged.Species == "" {
+		merged.Species = detail.Species
+	}
+	if merged.Sex == core.SexUnknown {
+		merged.Sex = detail.Sex
+	}
+	if merged.Breed == "" {
+		merged.Breed = detail.Breed
+	}
+	if merged.AgeMonths == 0 {
+		merged.AgeMonths = detail.AgeMonths
+	}
+	merged.Urgent = merged.Urgent || detail.Urgent
+	return &merged
+}
@@ -0,0 +1,119 @@
+package rememberme
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/maximerauch/go-classifieds-watcher/internal/core"
+)
+
+func TestParseAnimal(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		classes     string
+		want        core.AnimalAttributes
+	}{
+		{
+			name:        "Complete description",
+			description: "Chien mâle, 3 ans. Race : Berger Allemand, sociable",
+			want:        core.AnimalAttributes{Species: "dog", Sex: core.SexMale, Breed: "Berger Allemand", AgeMonths: 36},
+		},
+		{
+			name:        "Female and age in months",
+			description: "Adorable chatte de 8 mois",
+			want:        core.AnimalAttributes{Species: "cat", Sex: core.SexFemale, AgeMonths: 8},
+		},
+		{
+			name:        "Urgency from article classes",
+			description: "Femelle croisée",
+			classes:     "pets type-pets urgence-oui",
+			want:        core.AnimalAttributes{Sex: core.SexFemale, Urgent: true},
+		},
+		{
+			name:        "Other urgency terms",
+			description: "Femelle croisée",
+			classes:     "pets type-pets urgence-non",
+			want:        core.AnimalAttributes{Sex: core.SexFemale},
+		},
+		{
+			name:        "Urgency from description",
+			description: "Adoption urgente, chien de 5 ans",
+			want:        core.AnimalAttributes{Species: "dog", AgeMonths: 60, Urgent: true},
+		},
+		{
+			name:        "Negated urgency",
+			description: "Chien de 5 ans, pas urgent. Non-urgent mais à placer",
+			want:        core.AnimalAttributes{Species: "dog", AgeMonths: 60},
+		},
+		{
+			name:        "Urgency within a word",
+			description: "Chienne insurgente",
+			want:        core.AnimalAttributes{Species: "dog", Sex: core.SexFemale},
+		},
+		{
+			name:        "Cat getting along with dogs",
+			description: "Chat mâle de 2 ans, il s'entend bien avec les chiens",
+			want:        core.AnimalAttributes{Species: "cat", Sex: core.SexMale, AgeMonths: 24},
+		},
+		{
+			name:        "Purchase is no cat",
+			description: "Adoption responsable, aucun achat",
+			want:        core.AnimalAttributes{},
+		},
+		{
+			name:        "Taxonomy over description",
+			description: "Vit avec un chien et deux femelles",
+			classes:     "pets type-pets espece-chat sexe-male",
+			want:        core.AnimalAttributes{Species: "cat", Sex: core.SexMale},
+		},
+		{
+			name:        "Nothing published",
+			description: "Venez le rencontrer",
+			want:        core.AnimalAttributes{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseAnimal(tt.description, tt.classes); *got != tt.want {
+				t.Errorf("parseAnimal() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnrichFromDocument(t *testing.T) {
+	page := `<html><head>
+		<meta property="og:image" content="https://site.org/rex-main.jpg">
+		<meta property="article:published_time" content="2024-03-01T10:00:00+00:00">
+	</head><body><div class="entry-content">
+		<p>Rex est un chien mâle de 3 ans.</p>
+		<p>Race : Beauceron, il adore les balades.</p>
+		<img src="https://site.org/rex-main.jpg"><img src="https://site.org/rex-2.jpg">
+	</div></body></html>`
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("Failed to parse page: %v", err)
+	}
+
+	listed := core.Item{ID: "pet-1", Title: "Rex", Images: []string{"https://site.org/rex-150x150.jpg"}, Animal: &core.AnimalAttributes{Urgent: true}}
+	item := enrichFromDocument(listed, doc)
+
+	if item.FullDescription != "Rex est un chien mâle de 3 ans. Race : Beauceron, il adore les balades." {
+		t.Errorf("FullDescription = %q", item.FullDescription)
+	}
+	if len(item.Images) != 2 || item.Images[0] != "https://site.org/rex-main.jpg" {
+		t.Errorf("Images = %v, want the detail photos replacing the thumbnail, featured first, without duplicates", item.Images)
+	}
+	if item.PublishedAt.IsZero() || item.PublishedAt.Year() != 2024 {
+		t.Errorf("PublishedAt = %v, want the article publication date", item.PublishedAt)
+	}
+
+	want := core.AnimalAttributes{Species: "dog", Sex: core.SexMale, Breed: "Beauceron", AgeMonths: 36, Urgent: true}
+	if *item.Animal != want {
+		t.Errorf("Animal = %+v, want %+v", *item.Animal, want)
+	}
+}
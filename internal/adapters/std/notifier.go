@@ -20,6 +20,43 @@ func (n *LoggerNotifier) Send(ctx context.Context, item core.Item) error {
 		"title", item.Title,
 		"price", item.Price,
 		"url", item.Url,
+		"image", primaryImage(item),
 	)
 	return nil
 }
+
+func (n *LoggerNotifier) SendChange(ctx context.Context, change core.ItemChange) error {
+	n.logger.Info("CHANGE NOTIFICATION SENT",
+		"title", change.Item.Title,
+		"old_price", change.Previous.Price,
+		"price", change.Item.Price,
+		"changes", len(change.Changes),
+		"url", change.Item.Url,
+		"image", primaryImage(change.Item),
+	)
+	return nil
+}
+
+func (n *LoggerNotifier) SendRemoval(ctx context.Context, item core.Item) error {
+	n.logger.Info("REMOVAL NOTIFICATION SENT",
+		"title", item.Title,
+		"url", item.Url,
+	)
+	return nil
+}
+
+func (n *LoggerNotifier) SendSummary(ctx context.Context, summary core.BootstrapSummary) error {
+	n.logger.Info("SUMMARY NOTIFICATION SENT",
+		"provider", summary.Provider,
+		"items", summary.Items,
+	)
+	return nil
+}
+
+// primaryImage returns the URL of the main photo of an item, "" when it has none.
+func primaryImage(item core.Item) string {
+	if len(item.Images) == 0 {
+		return ""
+	}
+	return item.Images[0]
+}
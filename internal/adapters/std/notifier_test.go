@@ -30,10 +30,11 @@ func TestLoggerNotifier_Send(t *testing.T) {
 	notifier := NewLoggerNotifier(logger)
 
 	item := core.Item{
-		ID:    "123",
-		Title: "Gibson Les Paul",
-		Price: 2500.50,
-		Url:   "https://example.com/guitar",
+		ID:     "123",
+		Title:  "Gibson Les Paul",
+		Price:  2500.50,
+		Url:    "https://example.com/guitar",
+		Images: []string{"https://example.com/guitar.jpg", "https://example.com/back.jpg"},
 	}
 
 	// 2. ACT
@@ -54,6 +55,7 @@ func TestLoggerNotifier_Send(t *testing.T) {
 		"title=\"Gibson Les Paul\"",
 		"price=2500.5",
 		"url=https://example.com/guitar",
+		"image=https://example.com/guitar.jpg",
 	}
 
 	for _, s := range expectedSubstrings {
@@ -1,81 +1,507 @@
 package config
 
 import (
+	"cmp"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type AppConfig struct {
-	Asi67      Asi67Config
-	RememberMe RememberMeConfig
-	Email      EmailConfig
-	Database   DatabaseConfig
+	Watcher       WatcherConfig        `yaml:"watcher"`
+	Repository    RepositoryConfig     `yaml:"repository"`
+	Outbox        OutboxConfig         `yaml:"outbox"`
+	Subscriptions []SubscriptionConfig `yaml:"subscriptions"` // Everyone gets every item when empty
+	Watches       []WatchConfig        `yaml:"watches"`       // Named searches of the configuration file
+	Asi67         Asi67Config          `yaml:"asi67"`
+	RememberMe    RememberMeConfig     `yaml:"rememberme"`
+	Email         EmailConfig          `yaml:"email"`
+	Database      DatabaseConfig       `yaml:"database"`
+}
+
+type WatcherConfig struct {
+	Providers           []string       `yaml:"providers"` // Enabled sources, e.g. "asi67", "rememberme"
+	Notifiers           []string       `yaml:"notifiers"` // Enabled notification channels, e.g. "log", "email"
+	ProviderConcurrency int            `yaml:"provider_concurrency"`
+	EnrichConcurrency   int            `yaml:"enrich_concurrency"`   // Detail pages fetched in parallel per provider
+	ChangeNotifications string         `yaml:"change_notifications"` // "all", "price-drop" or "off"
+	RemovalThreshold    int            `yaml:"removal_threshold"`    // Consecutive complete scans an item must be missing from, 0 disables
+	NotifyRemovals      bool           `yaml:"notify_removals"`
+	Bootstrap           string         `yaml:"bootstrap"` // "auto", "force" or "off": seeding a provider without notifications
+	BootstrapSummary    bool           `yaml:"bootstrap_summary"`
+	Schedule            ScheduleConfig `yaml:"schedule"` // Default schedule of the providers
+}
+
+// ScheduleConfig tells when a provider is scanned.
+type ScheduleConfig struct {
+	Spec    string        `yaml:"spec"`    // "@every 15m" or a cron expression like "*/10 7-23 * * *"
+	Jitter  time.Duration `yaml:"jitter"`  // Upper bound of the random delay added to each run
+	Timeout time.Duration `yaml:"timeout"` // Maximum duration of a run
+}
+
+// RepositoryConfig selects where seen items are stored.
+type RepositoryConfig struct {
+	Backend      string `yaml:"backend"`        // "postgres" or "json"
+	DataFilePath string `yaml:"data_file_path"` // JSON file of the sources without their own DATA_FILE_PATH
+}
+
+// OutboxConfig controls the retries of persisted notifications.
+type OutboxConfig struct {
+	MaxAttempts  int           `yaml:"max_attempts"`
+	BaseDelay    time.Duration `yaml:"base_delay"`
+	MaxDelay     time.Duration `yaml:"max_delay"`
+	Retention    time.Duration `yaml:"retention"`      // Age of the sent and dead-lettered deliveries pruned, 0 keeps them
+	DataFilePath string        `yaml:"data_file_path"` // JSON file of the outbox, with the json repository backend
+}
+
+// SubscriptionConfig describes a saved search and who is notified of its items.
+type SubscriptionConfig struct {
+	Name      string           `yaml:"name"`
+	Providers []string         `yaml:"providers"` // Sources watched ("asi67", "rememberme"), all of them when empty
+	Notifiers []string         `yaml:"notifiers"` // Channels of the subscription, WATCHER_NOTIFIERS when empty
+	EmailTo   []string         `yaml:"email_to"`  // Recipients, EMAIL_TO when empty
+	Quiet     QuietHoursConfig `yaml:"quiet"`     // Quiet hours of the subscriber's emails, EMAIL_QUIET_HOURS when empty
+	Filter    FilterConfig     `yaml:"filter"`
+}
+
+// WatchConfig describes a named search of a provider, declared in the configuration file.
+type WatchConfig struct {
+	Name         string         `yaml:"name"`          // Key of the watch, used by subscriptions and commands
+	Type         string         `yaml:"type"`          // Provider type: "asi67" or "rememberme"
+	ProviderName string         `yaml:"provider_name"` // Name its items are stored under, the watch name when empty
+	Search       SearchConfig   `yaml:"search"`
+	Schedule     ScheduleConfig `yaml:"schedule"` // Unset fields fall back to the watcher schedule
+	Filter       FilterConfig   `yaml:"filter"`
+	Notify       NotifyConfig   `yaml:"notify"`
+	DataFilePath string         `yaml:"data_file_path"` // JSON file of its items, REPOSITORY_DATA_FILE_PATH when empty
+}
+
+// SearchConfig holds the provider-specific parameters of a watch.
+// Unset parameters fall back to the settings of the provider type.
+type SearchConfig struct {
+	URL               string `yaml:"url"`            // asi67 API URL or rememberme search page
+	ItemsPerPage      int    `yaml:"items_per_page"` // asi67 only
+	Asi67SearchConfig `yaml:",inline"`
+}
+
+// Asi67SearchConfig holds the criteria of an asi67 search.
+// Those the asi67 search form does not take, like the surface or the rooms, are applied to the listings received.
+type Asi67SearchConfig struct {
+	Offer        string  `yaml:"offer"`         // "rent" or "sale"
+	PropertyType string  `yaml:"property_type"` // e.g. "appt" or "maison"
+	Location     string  `yaml:"location"`      // "<city>/<postal code>", e.g. "strasbourg/67000"
+	RadiusKm     int     `yaml:"radius_km"`
+	BudgetMin    float64 `yaml:"budget_min"` // Monthly rent, or sale price
+	BudgetMax    float64 `yaml:"budget_max"`
+	SurfaceMin   float64 `yaml:"surface_min"`
+	SurfaceMax   float64 `yaml:"surface_max"`
+	RoomsMin     int     `yaml:"rooms_min"`
+	RoomsMax     int     `yaml:"rooms_max"`
+}
+
+// NotifyConfig lists the targets notified of the items of a watch.
+type NotifyConfig struct {
+	Notifiers []string         `yaml:"notifiers"` // WATCHER_NOTIFIERS when empty
+	EmailTo   []string         `yaml:"email_to"`  // EMAIL_TO when empty
+	Quiet     QuietHoursConfig `yaml:"quiet"`     // EMAIL_QUIET_HOURS when empty
+}
+
+// Targeted tells whether the watch notifies its own targets instead of the default ones.
+func (n NotifyConfig) Targeted() bool {
+	return len(n.Notifiers) > 0 || len(n.EmailTo) > 0 || n.Quiet.Window != ""
+}
+
+// QuietHoursConfig describes a daily window during which a channel is not notified.
+type QuietHoursConfig struct {
+	Window      string `yaml:"window"`       // e.g. "22:00-07:00", empty disables quiet hours
+	Timezone    string `yaml:"timezone"`     // e.g. "Europe/Paris", the local timezone when empty
+	AllowUrgent bool   `yaml:"allow_urgent"` // Urgent listings are delivered during the window
 }
 
 type Asi67Config struct {
-	APIURL       string
-	ItemsPerPage int
-	DataFilePath string
+	APIURL       string            `yaml:"api_url"`
+	ItemsPerPage int               `yaml:"items_per_page"`
+	Search       Asi67SearchConfig `yaml:"search"`
+	DataFilePath string            `yaml:"data_file_path"`
+	Filter       FilterConfig      `yaml:"filter"`
+	Schedule     ScheduleConfig    `yaml:"schedule"`
 }
 
 type RememberMeConfig struct {
-	SearchURL    string
-	DataFilePath string
+	SearchURL    string         `yaml:"search_url"`
+	DataFilePath string         `yaml:"data_file_path"`
+	Filter       FilterConfig   `yaml:"filter"`
+	Schedule     ScheduleConfig `yaml:"schedule"`
+}
+
+// FilterConfig describes the rules items of a source must satisfy to be notified.
+type FilterConfig struct {
+	MinPrice float64  `yaml:"min_price"`
+	MaxPrice float64  `yaml:"max_price"`
+	Include  []string `yaml:"include"`
+	Exclude  []string `yaml:"exclude"`
+	Pattern  string   `yaml:"pattern"` // Regular expression matched against the title or description
+	Ranges   string   `yaml:"ranges"`  // Numeric attribute ranges, e.g. "surface:40:,rooms:2:4"
+	Matches  string   `yaml:"matches"` // Accepted attribute values, e.g. "city:Strasbourg|Schiltigheim,furnished:true"
 }
 
 type EmailConfig struct {
-	SMTPHost     string
-	SMTPPort     int
-	SMTPUser     string
-	SMTPPassword string
-	From         string
-	To           []string
+	SMTPHost     string           `yaml:"smtp_host"`
+	SMTPPort     int              `yaml:"smtp_port"`
+	SMTPUser     string           `yaml:"smtp_user"`
+	SMTPPassword string           `yaml:"smtp_password"`
+	From         string           `yaml:"from"`
+	To           []string         `yaml:"to"`
+	Quiet        QuietHoursConfig `yaml:"quiet"`
 }
 
 type DatabaseConfig struct {
-	DSN string
+	DSN string `yaml:"dsn"`
+}
+
+// Load reads the configuration from the environment and validates it.
+// Every problem found is reported at once by a *ValidationError.
+func Load(opts ...Option) (AppConfig, error) {
+	l := newLoader(opts)
+	return l.validate(l.fromEnv(Defaults()))
 }
 
-func Load() AppConfig {
+// Defaults returns the configuration used when nothing is set.
+// Secrets and recipients have no default: they must be configured when the email notifier or postgres is enabled.
+func Defaults() AppConfig {
 	return AppConfig{
+		Watcher: WatcherConfig{
+			Providers:           []string{"rememberme", "asi67"},
+			Notifiers:           []string{"log", "email"},
+			ProviderConcurrency: 2,
+			EnrichConcurrency:   4,
+			ChangeNotifications: "all",
+			RemovalThreshold:    3,
+			NotifyRemovals:      false,
+			Bootstrap:           "auto",
+			BootstrapSummary:    true,
+			// Providers are scanned every 15 minutes unless they override the watcher schedule
+			Schedule: ScheduleConfig{Spec: "@every 15m", Timeout: 2 * time.Minute},
+		},
+
+		Repository: RepositoryConfig{
+			Backend:      "postgres",
+			DataFilePath: "data/seen.json",
+		},
+
+		Outbox: OutboxConfig{
+			MaxAttempts:  10,
+			BaseDelay:    time.Minute,
+			MaxDelay:     4 * time.Hour,
+			Retention:    30 * 24 * time.Hour,
+			DataFilePath: "data/outbox.json",
+		},
+
+		Asi67: Asi67Config{
+			APIURL:       "https://www.asi67.com/webapi/getJson/Templates/ProductsList",
+			ItemsPerPage: 12,
+			Search: Asi67SearchConfig{
+				Offer:        "rent",
+				PropertyType: "appt",
+				Location:     "strasbourg/67000",
+				RadiusKm:     20,
+				BudgetMax:    1000,
+			},
+			DataFilePath: "data/asi67-seen.json",
+		},
+
+		RememberMe: RememberMeConfig{
+			SearchURL:    "https://remembermefrance.org/pets/?breed=0&pets_search%5Bsexe%5D=all&pets_search%5Bou_est_le_chien%5D=all&pets_search%5Burgence%5D=all",
+			DataFilePath: "data/rememberme-seen.json",
+		},
+
+		Email: EmailConfig{
+			SMTPPort: 587,
+			Quiet:    QuietHoursConfig{AllowUrgent: true},
+		},
+	}
+}
+
+// fromEnv overrides the values of base with the environment variables that are set.
+func (l *loader) fromEnv(base AppConfig) AppConfig {
+	schedule := l.loadSchedule("WATCHER_", base.Watcher.Schedule)
+
+	watches := make([]WatchConfig, len(base.Watches))
+	for i, w := range base.Watches {
+		w.Schedule = w.Schedule.or(schedule)
+		watches[i] = w
+	}
+
+	return AppConfig{
+		Watcher: WatcherConfig{
+			Providers:           l.getEnvAsListOr("WATCHER_PROVIDERS", base.Watcher.Providers),
+			Notifiers:           l.getEnvAsListOr("WATCHER_NOTIFIERS", base.Watcher.Notifiers),
+			ProviderConcurrency: l.getEnvAsInt("WATCHER_PROVIDER_CONCURRENCY", base.Watcher.ProviderConcurrency),
+			EnrichConcurrency:   l.getEnvAsInt("WATCHER_ENRICH_CONCURRENCY", base.Watcher.EnrichConcurrency),
+			ChangeNotifications: l.getEnv("WATCHER_CHANGE_NOTIFICATIONS", base.Watcher.ChangeNotifications),
+			RemovalThreshold:    l.getEnvAsInt("WATCHER_REMOVAL_THRESHOLD", base.Watcher.RemovalThreshold),
+			NotifyRemovals:      l.getEnvAsBool("WATCHER_NOTIFY_REMOVALS", base.Watcher.NotifyRemovals),
+			Bootstrap:           l.getEnv("WATCHER_BOOTSTRAP", base.Watcher.Bootstrap),
+			BootstrapSummary:    l.getEnvAsBool("WATCHER_BOOTSTRAP_SUMMARY", base.Watcher.BootstrapSummary),
+			Schedule:            schedule,
+		},
+
+		Repository: RepositoryConfig{
+			Backend:      l.getEnv("REPOSITORY_BACKEND", base.Repository.Backend),
+			DataFilePath: l.getEnv("REPOSITORY_DATA_FILE_PATH", base.Repository.DataFilePath),
+		},
+
+		Outbox: OutboxConfig{
+			MaxAttempts:  l.getEnvAsInt("OUTBOX_MAX_ATTEMPTS", base.Outbox.MaxAttempts),
+			BaseDelay:    l.getEnvAsDuration("OUTBOX_BASE_DELAY", base.Outbox.BaseDelay),
+			MaxDelay:     l.getEnvAsDuration("OUTBOX_MAX_DELAY", base.Outbox.MaxDelay),
+			Retention:    l.getEnvAsDuration("OUTBOX_RETENTION", base.Outbox.Retention),
+			DataFilePath: l.getEnv("OUTBOX_DATA_FILE_PATH", base.Outbox.DataFilePath),
+		},
+
+		Subscriptions: l.loadSubscriptions(base.Subscriptions),
+		Watches:       watches,
+
 		Asi67: Asi67Config{
-			APIURL:       getEnv("ASI67_API_URL", "https://www.asi67.com/webapi/getJson/Templates/ProductsList"),
-			ItemsPerPage: getEnvAsInt("ASI67_ITEMS_PER_PAGE", 12),
-			DataFilePath: getEnv("ASI67_DATA_FILE_PATH", "data/asi67-seen.json"),
+			APIURL:       l.getEnv("ASI67_API_URL", base.Asi67.APIURL),
+			ItemsPerPage: l.getEnvAsInt("ASI67_ITEMS_PER_PAGE", base.Asi67.ItemsPerPage),
+			Search:       l.loadAsi67Search("ASI67_SEARCH_", base.Asi67.Search),
+			DataFilePath: l.getEnv("ASI67_DATA_FILE_PATH", base.Asi67.DataFilePath),
+			Filter:       l.loadFilter("ASI67_FILTER_", base.Asi67.Filter),
+			Schedule:     l.loadSchedule("ASI67_", base.Asi67.Schedule.or(schedule)),
 		},
 
 		RememberMe: RememberMeConfig{
-			SearchURL:    getEnv("REMEMBERME_SEARCH_URL", "https://remembermefrance.org/pets/?breed=0&pets_search%5Bsexe%5D=all&pets_search%5Bou_est_le_chien%5D=all&pets_search%5Burgence%5D=all"),
-			DataFilePath: getEnv("REMEMBERME_DATA_FILE_PATH", "data/rememberme-seen.json"),
+			SearchURL:    l.getEnv("REMEMBERME_SEARCH_URL", base.RememberMe.SearchURL),
+			DataFilePath: l.getEnv("REMEMBERME_DATA_FILE_PATH", base.RememberMe.DataFilePath),
+			Filter:       l.loadFilter("REMEMBERME_FILTER_", base.RememberMe.Filter),
+			Schedule:     l.loadSchedule("REMEMBERME_", base.RememberMe.Schedule.or(schedule)),
 		},
 
 		Email: EmailConfig{
-			SMTPHost:     getEnv("SMTP_HOST", "smtp.gmail.com"),
-			SMTPPort:     getEnvAsInt("SMTP_PORT", 587),
-			SMTPUser:     getEnv("SMTP_USER", "your.mail@gmail.com"),
-			SMTPPassword: getEnv("SMTP_PASSWORD", "your-password"),
-			From:         getEnv("EMAIL_FROM", "Watcher Bot <your.mail@gmail.com>"),
-			To:           strings.Split(getEnv("EMAIL_TO", "your.mail@gmail.com"), ","),
+			SMTPHost:     l.getEnv("SMTP_HOST", base.Email.SMTPHost),
+			SMTPPort:     l.getEnvAsInt("SMTP_PORT", base.Email.SMTPPort),
+			SMTPUser:     l.getEnv("SMTP_USER", base.Email.SMTPUser),
+			SMTPPassword: l.getSecret("SMTP_PASSWORD", base.Email.SMTPPassword),
+			From:         l.getEnv("EMAIL_FROM", base.Email.From),
+			To:           l.getEnvAsListOr("EMAIL_TO", base.Email.To),
+			Quiet:        l.loadQuietHours("EMAIL_", base.Email.Quiet),
 		},
 
 		Database: DatabaseConfig{
-			DSN: getEnv("DATABASE_URL", ""),
+			DSN: l.getSecret("DATABASE_URL", base.Database.DSN),
 		},
 	}
 }
 
-func getEnv(key, fallback string) string {
+// Sources returns the watches to run: the provider sections enabled by Watcher.Providers,
+// keyed by provider type, followed by the watches of the configuration file.
+// Unset search parameters of a watch fall back to the section of its provider type.
+func (c AppConfig) Sources() []WatchConfig {
+	var sources []WatchConfig
+	for _, key := range c.Watcher.Providers {
+		source := WatchConfig{Name: key, Type: key}
+		switch key {
+		case "asi67":
+			source.Search = SearchConfig{URL: c.Asi67.APIURL, ItemsPerPage: c.Asi67.ItemsPerPage, Asi67SearchConfig: c.Asi67.Search}
+			source.Schedule, source.Filter, source.DataFilePath = c.Asi67.Schedule, c.Asi67.Filter, c.Asi67.DataFilePath
+		case "rememberme":
+			source.Search = SearchConfig{URL: c.RememberMe.SearchURL}
+			source.Schedule, source.Filter, source.DataFilePath = c.RememberMe.Schedule, c.RememberMe.Filter, c.RememberMe.DataFilePath
+		}
+		sources = append(sources, source)
+	}
+
+	for _, w := range c.Watches {
+		switch w.Type {
+		case "asi67":
+			w.Search.URL = cmp.Or(w.Search.URL, c.Asi67.APIURL)
+			w.Search.ItemsPerPage = cmp.Or(w.Search.ItemsPerPage, c.Asi67.ItemsPerPage)
+			w.Search.Asi67SearchConfig = w.Search.Asi67SearchConfig.or(c.Asi67.Search)
+		case "rememberme":
+			w.Search.URL = cmp.Or(w.Search.URL, c.RememberMe.SearchURL)
+		}
+		sources = append(sources, w)
+	}
+	return sources
+}
+
+// AllSubscriptions returns the saved searches, followed by a subscription per watch of the configuration file
+// when one of them notifies its own targets: the other watches then notify the default ones.
+func (c AppConfig) AllSubscriptions() []SubscriptionConfig {
+	subscriptions := slices.Clone(c.Subscriptions)
+	if !slices.ContainsFunc(c.Watches, func(w WatchConfig) bool { return w.Notify.Targeted() }) {
+		return subscriptions
+	}
+
+	for _, w := range c.Watches {
+		subscriptions = append(subscriptions, SubscriptionConfig{
+			Name:      w.Name,
+			Providers: []string{w.Name},
+			Notifiers: w.Notify.Notifiers,
+			EmailTo:   w.Notify.EmailTo,
+			Quiet:     w.Notify.Quiet,
+		})
+	}
+	return subscriptions
+}
+
+// or fills the unset criteria of a search with those of fallback.
+func (s Asi67SearchConfig) or(fallback Asi67SearchConfig) Asi67SearchConfig {
+	return Asi67SearchConfig{
+		Offer:        cmp.Or(s.Offer, fallback.Offer),
+		PropertyType: cmp.Or(s.PropertyType, fallback.PropertyType),
+		Location:     cmp.Or(s.Location, fallback.Location),
+		RadiusKm:     cmp.Or(s.RadiusKm, fallback.RadiusKm),
+		BudgetMin:    cmp.Or(s.BudgetMin, fallback.BudgetMin),
+		BudgetMax:    cmp.Or(s.BudgetMax, fallback.BudgetMax),
+		SurfaceMin:   cmp.Or(s.SurfaceMin, fallback.SurfaceMin),
+		SurfaceMax:   cmp.Or(s.SurfaceMax, fallback.SurfaceMax),
+		RoomsMin:     cmp.Or(s.RoomsMin, fallback.RoomsMin),
+		RoomsMax:     cmp.Or(s.RoomsMax, fallback.RoomsMax),
+	}
+}
+
+// or fills the unset fields of a schedule with those of fallback.
+func (s ScheduleConfig) or(fallback ScheduleConfig) ScheduleConfig {
+	return ScheduleConfig{
+		Spec:    cmp.Or(s.Spec, fallback.Spec),
+		Jitter:  cmp.Or(s.Jitter, fallback.Jitter),
+		Timeout: cmp.Or(s.Timeout, fallback.Timeout),
+	}
+}
+
+// loadSubscriptions reads the subscriptions listed by SUBSCRIPTIONS, e.g. "dogs,flats", replacing those of base.
+// Each one is configured by the variables starting with SUBSCRIPTION_<NAME>_, e.g. SUBSCRIPTION_DOGS_EMAIL_TO.
+func (l *loader) loadSubscriptions(base []SubscriptionConfig) []SubscriptionConfig {
+	names := l.getEnvAsList("SUBSCRIPTIONS")
+	if len(names) == 0 {
+		return base
+	}
+
+	var subscriptions []SubscriptionConfig
+	for _, name := range names {
+		prefix := "SUBSCRIPTION_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		subscriptions = append(subscriptions, SubscriptionConfig{
+			Name:      name,
+			Providers: l.getEnvAsList(prefix + "PROVIDERS"),
+			Notifiers: l.getEnvAsList(prefix + "NOTIFIERS"),
+			EmailTo:   l.getEnvAsList(prefix + "EMAIL_TO"),
+			Quiet:     l.loadQuietHours(prefix, QuietHoursConfig{AllowUrgent: true}),
+			Filter:    l.loadFilter(prefix+"FILTER_", FilterConfig{}),
+		})
+	}
+	return subscriptions
+}
+
+// loadAsi67Search reads the criteria of the asi67 search from the variables starting with prefix, overriding base.
+func (l *loader) loadAsi67Search(prefix string, base Asi67SearchConfig) Asi67SearchConfig {
+	return Asi67SearchConfig{
+		Offer:        l.getEnv(prefix+"OFFER", base.Offer),
+		PropertyType: l.getEnv(prefix+"PROPERTY_TYPE", base.PropertyType),
+		Location:     l.getEnv(prefix+"LOCATION", base.Location),
+		RadiusKm:     l.getEnvAsInt(prefix+"RADIUS_KM", base.RadiusKm),
+		BudgetMin:    l.getEnvAsFloat(prefix+"BUDGET_MIN", base.BudgetMin),
+		BudgetMax:    l.getEnvAsFloat(prefix+"BUDGET_MAX", base.BudgetMax),
+		SurfaceMin:   l.getEnvAsFloat(prefix+"SURFACE_MIN", base.SurfaceMin),
+		SurfaceMax:   l.getEnvAsFloat(prefix+"SURFACE_MAX", base.SurfaceMax),
+		RoomsMin:     l.getEnvAsInt(prefix+"ROOMS_MIN", base.RoomsMin),
+		RoomsMax:     l.getEnvAsInt(prefix+"ROOMS_MAX", base.RoomsMax),
+	}
+}
+
+// loadFilter reads the filter rules of a source from the variables starting with prefix, overriding base.
+func (l *loader) loadFilter(prefix string, base FilterConfig) FilterConfig {
+	return FilterConfig{
+		MinPrice: l.getEnvAsFloat(prefix+"MIN_PRICE", base.MinPrice),
+		MaxPrice: l.getEnvAsFloat(prefix+"MAX_PRICE", base.MaxPrice),
+		Include:  l.getEnvAsListOr(prefix+"INCLUDE", base.Include),
+		Exclude:  l.getEnvAsListOr(prefix+"EXCLUDE", base.Exclude),
+		Pattern:  l.getEnv(prefix+"PATTERN", base.Pattern),
+		Ranges:   l.getEnv(prefix+"RANGES", base.Ranges),
+		Matches:  l.getEnv(prefix+"MATCHES", base.Matches),
+	}
+}
+
+// loadSchedule reads the variables <PREFIX>SCHEDULE, <PREFIX>JITTER and <PREFIX>TIMEOUT,
+// each one falling back to its value in fallback when unset or empty.
+func (l *loader) loadSchedule(prefix string, fallback ScheduleConfig) ScheduleConfig {
+	spec := l.getEnv(prefix+"SCHEDULE", "")
+	if spec == "" {
+		spec = fallback.Spec
+	}
+	return ScheduleConfig{
+		Spec:    spec,
+		Jitter:  l.getEnvAsDuration(prefix+"JITTER", fallback.Jitter),
+		Timeout: l.getEnvAsDuration(prefix+"TIMEOUT", fallback.Timeout),
+	}
+}
+
+// loadQuietHours reads the variables <PREFIX>QUIET_HOURS, <PREFIX>QUIET_HOURS_TZ and <PREFIX>QUIET_HOURS_ALLOW_URGENT, overriding base.
+func (l *loader) loadQuietHours(prefix string, base QuietHoursConfig) QuietHoursConfig {
+	return QuietHoursConfig{
+		Window:      l.getEnv(prefix+"QUIET_HOURS", base.Window),
+		Timezone:    l.getEnv(prefix+"QUIET_HOURS_TZ", base.Timezone),
+		AllowUrgent: l.getEnvAsBool(prefix+"QUIET_HOURS_ALLOW_URGENT", base.AllowUrgent),
+	}
+}
+
+func (l *loader) getEnv(key, fallback string) string {
+	l.read[key] = true
 	if value, exists := os.LookupEnv(key); exists {
 		return value
 	}
 	return fallback
 }
 
-func getEnvAsInt(key string, fallback int) int {
-	valueStr := getEnv(key, "")
-	if value, err := strconv.Atoi(valueStr); err == nil {
-		return value
+// parseEnv parses the value of a variable, returning fallback when it is unset or empty.
+// Malformed values are reported as problems.
+func parseEnv[T any](l *loader, key string, fallback T, parse func(string) (T, error), want string) T {
+	valueStr := l.getEnv(key, "")
+	if valueStr == "" {
+		return fallback
+	}
+	value, err := parse(valueStr)
+	if err != nil {
+		l.problemf("%s: %q is not %s", key, valueStr, want)
+		return fallback
+	}
+	return value
+}
+
+func (l *loader) getEnvAsInt(key string, fallback int) int {
+	return parseEnv(l, key, fallback, strconv.Atoi, "an integer")
+}
+
+func (l *loader) getEnvAsBool(key string, fallback bool) bool {
+	return parseEnv(l, key, fallback, strconv.ParseBool, "a boolean (true or false)")
+}
+
+func (l *loader) getEnvAsFloat(key string, fallback float64) float64 {
+	return parseEnv(l, key, fallback, func(s string) (float64, error) { return strconv.ParseFloat(s, 64) }, "a number")
+}
+
+// getEnvAsList splits a comma-separated value, ignoring blank entries.
+func (l *loader) getEnvAsList(key string) []string {
+	var list []string
+	for _, value := range strings.Split(l.getEnv(key, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// getEnvAsListOr reads a comma-separated list, returning fallback when it is unset or empty.
+func (l *loader) getEnvAsListOr(key string, fallback []string) []string {
+	if list := l.getEnvAsList(key); len(list) > 0 {
+		return list
 	}
 	return fallback
 }
+
+func (l *loader) getEnvAsDuration(key string, fallback time.Duration) time.Duration {
+	return parseEnv(l, key, fallback, time.ParseDuration, "a duration like 30s or 15m")
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// variablePattern matches the ${NAME} and ${NAME:-default} references of a configuration file.
+var variablePattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// LoadFile reads the YAML configuration file at path, then overrides its values with the environment variables that are set.
+// Values of the file may reference environment variables as ${NAME}, or ${NAME:-default} when they may be unset.
+// The result is validated as by Load, unknown keys of the file being reported too.
+func LoadFile(path string, opts ...Option) (AppConfig, error) {
+	l := newLoader(opts)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return AppConfig{}, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return AppConfig{}, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	interpolate(&root, path, l)
+
+	// Sources of the environment are only enabled by default when the file declares no watch
+	cfg := Defaults()
+	cfg.Watcher.Providers = nil
+	if len(root.Content) > 0 {
+		checkKeys(root.Content[0], reflect.TypeFor[AppConfig](), path, "", l)
+
+		// Values of the wrong type are reported along with the other problems
+		var typeErr *yaml.TypeError
+		if err := root.Decode(&cfg); errors.As(err, &typeErr) {
+			for _, problem := range typeErr.Errors {
+				l.problemf("%s: %s", path, problem)
+			}
+		} else if err != nil {
+			return AppConfig{}, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	}
+	if cfg.Watcher.Providers == nil && len(cfg.Watches) == 0 {
+		cfg.Watcher.Providers = Defaults().Watcher.Providers
+	}
+
+	for i, w := range cfg.Watches {
+		if w.ProviderName == "" {
+			cfg.Watches[i].ProviderName = w.Name
+		}
+	}
+
+	return l.validate(l.fromEnv(cfg))
+}
+
+// checkKeys reports the keys of node that are not decoded into a field of t, path being the key of node in file.
+func checkKeys(node *yaml.Node, t reflect.Type, file, path string, l *loader) {
+	switch {
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, ok := fields[key.Value]
+			if !ok {
+				l.problemf("%s:%d: unknown key %s%s", file, key.Line, path, key.Value)
+				continue
+			}
+			checkKeys(value, field, file, path+key.Value+".", l)
+		}
+	case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		for i, item := range node.Content {
+			checkKeys(item, t.Elem(), file, fmt.Sprintf("%s%d.", path, i), l)
+		}
+	}
+}
+
+// yamlFields returns the type of the fields of struct t, keyed by YAML name, inlined structs included.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := range t.NumField() {
+		name, options, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		switch {
+		case options == "inline":
+			maps.Copy(fields, yamlFields(t.Field(i).Type))
+		case name != "":
+			fields[name] = t.Field(i).Type
+		}
+	}
+	return fields
+}
+
+// interpolate replaces the environment variable references of every scalar value of file.
+func interpolate(node *yaml.Node, file string, l *loader) {
+	if node.Kind == yaml.ScalarNode && strings.Contains(node.Value, "${") {
+		node.Value = variablePattern.ReplaceAllStringFunc(node.Value, func(ref string) string {
+			match := variablePattern.FindStringSubmatch(ref)
+			l.read[match[1]] = true
+			if value, ok := os.LookupEnv(match[1]); ok {
+				return value
+			}
+			if match[2] == "" {
+				l.problemf("%s:%d: environment variable %s is not set, write ${%s:-} to allow it", file, node.Line, match[1], match[1])
+			}
+			return match[3]
+		})
+		// The value was written as a reference, its type comes from the field it is decoded into
+		node.Tag = ""
+	}
+
+	for _, child := range node.Content {
+		interpolate(child, file, l)
+	}
+}
+
+// UnmarshalYAML delivers urgent listings during quiet hours unless allow_urgent is false,
+// as with the environment variables.
+func (q *QuietHoursConfig) UnmarshalYAML(node *yaml.Node) error {
+	type plain QuietHoursConfig
+	value := plain{AllowUrgent: true}
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+	*q = QuietHoursConfig(value)
+	return nil
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// redacted replaces secrets wherever the configuration is printed or logged.
+const redacted = "xxxxx"
+
+// getSecret reads a secret from the variable key, or from the file named by <KEY>_FILE,
+// e.g. a Docker or Kubernetes secret mount, keeping it out of the process environment.
+func (l *loader) getSecret(key, fallback string) string {
+	path := l.getEnv(key+"_FILE", "")
+	if path == "" {
+		return l.getEnv(key, fallback)
+	}
+
+	l.read[key] = true
+	if _, exists := os.LookupEnv(key); exists {
+		l.problemf("%s and %s_FILE are both set, keep only one of them", key, key)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		l.problemf("%s_FILE: %v", key, err)
+		return fallback
+	}
+	// Secret files usually end with a newline
+	return strings.TrimRight(string(data), "\r\n")
+}
+
+// RedactURL hides the password of a URL such as a database DSN.
+// Values that cannot be parsed are hidden entirely, as they may hold credentials.
+func RedactURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redacted
+	}
+	return u.Redacted()
+}
+
+// Redacted returns a copy of the configuration without its secrets, safe to print or log.
+func (c AppConfig) Redacted() AppConfig {
+	c.Email = c.Email.redacted()
+	c.Database = c.Database.redacted()
+	return c
+}
+
+// LogValue logs the configuration without its secrets.
+func (c AppConfig) LogValue() slog.Value {
+	type plain AppConfig // Without LogValue, not to resolve it again
+	return slog.AnyValue(plain(c.Redacted()))
+}
+
+func (c EmailConfig) redacted() EmailConfig {
+	if c.SMTPPassword != "" {
+		c.SMTPPassword = redacted
+	}
+	return c
+}
+
+func (c EmailConfig) String() string {
+	type plain EmailConfig // Without String, not to call it again
+	return fmt.Sprintf("%+v", plain(c.redacted()))
+}
+
+// LogValue logs the email settings without the SMTP password.
+func (c EmailConfig) LogValue() slog.Value {
+	type plain EmailConfig
+	return slog.AnyValue(plain(c.redacted()))
+}
+
+func (c DatabaseConfig) redacted() DatabaseConfig {
+	c.DSN = RedactURL(c.DSN)
+	return c
+}
+
+func (c DatabaseConfig) String() string {
+	type plain DatabaseConfig
+	return fmt.Sprintf("%+v", plain(c.redacted()))
+}
+
+// LogValue logs the database settings without the password of the DSN.
+func (c DatabaseConfig) LogValue() slog.Value {
+	type plain DatabaseConfig
+	return slog.AnyValue(plain(c.redacted()))
+}
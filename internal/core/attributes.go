@@ -0,0 +1,161 @@
+package core
+
+import (
+	"sort"
+	"strconv"
+)
+
+// RealEstateAttributes describes a housing listing.
+// Zero values mean the provider did not publish the information.
+type RealEstateAttributes struct {
+	Surface    float64 // Square meters
+	Rooms      int
+	City       string
+	PostalCode string
+	Rent       float64 // Monthly rent, charges excluded
+	Charges    float64 // Monthly charges
+	Furnished  bool
+}
+
+// Sex of an animal, as published by the shelter.
+type Sex string
+
+const (
+	SexUnknown Sex = ""
+	SexMale    Sex = "male"
+	SexFemale  Sex = "female"
+)
+
+// AnimalAttributes describes an adoption listing.
+// Zero values mean the provider did not publish the information.
+type AnimalAttributes struct {
+	Species   string // "dog", "cat"...
+	Sex       Sex
+	Breed     string
+	AgeMonths int
+	Urgent    bool // Adoption flagged as urgent by the shelter
+}
+
+// numericAttributes reads the numeric attributes of an item, for rules configured by name.
+var numericAttributes = map[string]func(Item) (float64, bool){
+	"price": func(i Item) (float64, bool) { return i.Price, i.Price > 0 },
+	"surface": func(i Item) (float64, bool) {
+		if i.RealEstate == nil {
+			return 0, false
+		}
+		return i.RealEstate.Surface, i.RealEstate.Surface > 0
+	},
+	"rooms": func(i Item) (float64, bool) {
+		if i.RealEstate == nil {
+			return 0, false
+		}
+		return float64(i.RealEstate.Rooms), i.RealEstate.Rooms > 0
+	},
+	"rent": func(i Item) (float64, bool) {
+		if i.RealEstate == nil {
+			return 0, false
+		}
+		return i.RealEstate.Rent, i.RealEstate.Rent > 0
+	},
+	"charges": func(i Item) (float64, bool) {
+		// No charges is a valid answer once the rent is known
+		if i.RealEstate == nil || i.RealEstate.Rent == 0 {
+			return 0, false
+		}
+		return i.RealEstate.Charges, true
+	},
+	"age_months": func(i Item) (float64, bool) {
+		if i.Animal == nil {
+			return 0, false
+		}
+		return float64(i.Animal.AgeMonths), i.Animal.AgeMonths > 0
+	},
+}
+
+// textAttributes reads the textual attributes of an item, for rules configured by name.
+var textAttributes = map[string]func(Item) (string, bool){
+	"city": func(i Item) (string, bool) {
+		if i.RealEstate == nil {
+			return "", false
+		}
+		return i.RealEstate.City, i.RealEstate.City != ""
+	},
+	"postal_code": func(i Item) (string, bool) {
+		if i.RealEstate == nil {
+			return "", false
+		}
+		return i.RealEstate.PostalCode, i.RealEstate.PostalCode != ""
+	},
+	"furnished": func(i Item) (string, bool) {
+		if i.RealEstate == nil {
+			return "", false
+		}
+		return strconv.FormatBool(i.RealEstate.Furnished), true
+	},
+	"species": func(i Item) (string, bool) {
+		if i.Animal == nil {
+			return "", false
+		}
+		return i.Animal.Species, i.Animal.Species != ""
+	},
+	"sex": func(i Item) (string, bool) {
+		if i.Animal == nil {
+			return "", false
+		}
+		return string(i.Animal.Sex), i.Animal.Sex != SexUnknown
+	},
+	"breed": func(i Item) (string, bool) {
+		if i.Animal == nil {
+			return "", false
+		}
+		return i.Animal.Breed, i.Animal.Breed != ""
+	},
+	"urgent": func(i Item) (string, bool) {
+		if i.Animal == nil {
+			return "", false
+		}
+		return strconv.FormatBool(i.Animal.Urgent), true
+	},
+}
+
+// Urgent reports whether the listing is flagged as urgent by its provider, like a shelter's urgent adoption.
+func (i Item) Urgent() bool {
+	return i.Animal != nil && i.Animal.Urgent
+}
+
+// NumericAttribute returns the value of a numeric attribute, like "surface" or "age_months".
+// The boolean is false when the item does not publish it.
+func (i Item) NumericAttribute(name string) (float64, bool) {
+	if read, ok := numericAttributes[name]; ok {
+		return read(i)
+	}
+	return 0, false
+}
+
+// TextAttribute returns the value of a textual attribute, like "city" or "species".
+// Booleans are rendered "true" or "false". The boolean is false when the item does not publish it.
+func (i Item) TextAttribute(name string) (string, bool) {
+	if read, ok := textAttributes[name]; ok {
+		return read(i)
+	}
+	return "", false
+}
+
+// NumericAttributes lists the names accepted by Item.NumericAttribute, for configuration checks.
+func NumericAttributes() []string {
+	return attributeNames(numericAttributes)
+}
+
+// TextAttributes lists the names accepted by Item.TextAttribute, for configuration checks.
+func TextAttributes() []string {
+	return attributeNames(textAttributes)
+}
+
+func attributeNames[T any](readers map[string]T) []string {
+	names := make([]string, 0, len(readers))
+	for name := range readers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -1,18 +1,89 @@
 package core
 
-import "time"
+import (
+	"strconv"
+	"time"
+)
 
 type Item struct {
 	ID          string
+	Provider    string // Name of the source provider, set by the WatcherService
 	Title       string
 	Description string
 	Price       float64
 	Currency    string
 	Url         string
 	PublishedAt time.Time
-	Metadata    map[string]interface{}
+
+	// Details filled by enrichment, when the provider implements Enricher
+	FullDescription string
+	Images          []string // Photo URLs, the primary one first
+
+	// Typed attributes, set by the providers they apply to
+	RealEstate *RealEstateAttributes
+	Animal     *AnimalAttributes
+}
+
+// ItemKey identifies an item across sources.
+// IDs are only unique within a provider, so the provider name is part of the identity.
+type ItemKey struct {
+	Provider string
+	ID       string
+}
+
+func (k ItemKey) String() string {
+	return k.Provider + "/" + k.ID
+}
+
+func (i Item) Key() ItemKey {
+	return ItemKey{Provider: i.Provider, ID: i.ID}
 }
 
 func (i Item) IsValid() bool {
 	return i.ID != "" && i.Title != "" && i.Url != ""
 }
+
+// FieldChange describes a field whose value differs between two snapshots of an item.
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// ItemChange is a known item whose listing was updated since it was last saved.
+type ItemChange struct {
+	Item     Item // Snapshot fetched during this run
+	Previous Item // Snapshot stored by the repository
+	Changes  []FieldChange
+}
+
+// PriceDropped reports whether the listing became cheaper.
+func (c ItemChange) PriceDropped() bool {
+	return c.Item.Price > 0 && c.Item.Price < c.Previous.Price
+}
+
+// Diff returns the field-level changes between two snapshots of the same item.
+// Values computed at fetch time, like PublishedAt, are not compared. Neither are the typed attributes
+// and the enriched details: they mostly restate the compared fields, are only fetched for some items,
+// and snapshots saved before they existed would all look changed.
+func Diff(previous, current Item) []FieldChange {
+	var changes []FieldChange
+
+	compare := func(field, old, new string) {
+		if old != new {
+			changes = append(changes, FieldChange{Field: field, Old: old, New: new})
+		}
+	}
+
+	compare("title", previous.Title, current.Title)
+	compare("description", previous.Description, current.Description)
+	compare("price", formatPrice(previous.Price), formatPrice(current.Price))
+	compare("currency", previous.Currency, current.Currency)
+	compare("url", previous.Url, current.Url)
+
+	return changes
+}
+
+func formatPrice(price float64) string {
+	return strconv.FormatFloat(price, 'f', 2, 64)
+}
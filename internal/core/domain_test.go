@@ -1,7 +1,9 @@
 package core
 
 import (
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestItem_IsValid(t *testing.T) {
@@ -56,3 +58,49 @@ func TestItem_IsValid(t *testing.T) {
 		})
 	}
 }
+
+func TestDiff(t *testing.T) {
+	previous := Item{
+		ID:          "123",
+		Title:       "T2 Schiltigheim",
+		Description: "45 m²",
+		Price:       900,
+		Currency:    "EUR",
+		Url:         "https://example.com/t2",
+	}
+
+	t.Run("Identical snapshots have no changes", func(t *testing.T) {
+		current := previous
+		current.PublishedAt = time.Now() // Set at fetch time, must be ignored
+
+		if changes := Diff(previous, current); len(changes) != 0 {
+			t.Errorf("Diff() = %v, want no changes", changes)
+		}
+	})
+
+	t.Run("Price drop is reported with old and new values", func(t *testing.T) {
+		current := previous
+		current.Price = 850
+
+		changes := Diff(previous, current)
+		want := []FieldChange{{Field: "price", Old: "900.00", New: "850.00"}}
+		if !reflect.DeepEqual(changes, want) {
+			t.Errorf("Diff() = %v, want %v", changes, want)
+		}
+
+		change := ItemChange{Item: current, Previous: previous, Changes: changes}
+		if !change.PriceDropped() {
+			t.Error("PriceDropped() = false, want true")
+		}
+	})
+
+	t.Run("Several fields changed", func(t *testing.T) {
+		current := previous
+		current.Title = "T2 Schiltigheim - refait à neuf"
+		current.Description = "45 m², balcon"
+
+		if changes := Diff(previous, current); len(changes) != 2 {
+			t.Errorf("Diff() returned %d changes, want 2: %v", len(changes), changes)
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sort"
+	"strings"
+)
+
+// PageError describes a page a provider failed to fetch.
+type PageError struct {
+	Page int
+	Err  error
+}
+
+func (e PageError) Error() string {
+	return fmt.Sprintf("page %d: %v", e.Page, e.Err)
+}
+
+func (e PageError) Unwrap() error {
+	return e.Err
+}
+
+// PartialFetchError is returned by providers along with the items of the pages fetched successfully,
+// when other pages of the scan failed. The scan must then be considered incomplete.
+type PartialFetchError struct {
+	Pages []PageError // Failed pages, sorted by page number
+	Total int         // Pages the scan was made of, 0 when unknown
+}
+
+// NewPartialFetchError returns nil when no page failed, so providers can return it unconditionally.
+func NewPartialFetchError(pages []PageError, total int) error {
+	if len(pages) == 0 {
+		return nil
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i].Page < pages[j].Page })
+	return &PartialFetchError{Pages: pages, Total: total}
+}
+
+func (e *PartialFetchError) Error() string {
+	causes := make([]string, len(e.Pages))
+	for i, page := range e.Pages {
+		causes[i] = page.Error()
+	}
+	if e.Total == 0 {
+		return fmt.Sprintf("%d pages failed: %s", len(e.Pages), strings.Join(causes, "; "))
+	}
+	return fmt.Sprintf("%d of %d pages failed: %s", len(e.Pages), e.Total, strings.Join(causes, "; "))
+}
+
+func (e *PartialFetchError) Unwrap() []error {
+	errs := make([]error, len(e.Pages))
+	for i, page := range e.Pages {
+		errs[i] = page
+	}
+	return errs
+}
+
+// failedPages returns every PageError found in err's tree.
+func failedPages(err error) []PageError {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case PageError:
+		return []PageError{e}
+	case interface{ Unwrap() []error }:
+		var pages []PageError
+		for _, inner := range e.Unwrap() {
+			pages = append(pages, failedPages(inner)...)
+		}
+		return pages
+	case interface{ Unwrap() error }:
+		return failedPages(e.Unwrap())
+	}
+	return nil
+}
+
+// Stream returns p as a StreamProvider.
+// Slice-based providers are adapted: their items are yielded once FetchItems returns, followed by its error.
+func Stream(p Provider) StreamProvider {
+	if sp, ok := p.(StreamProvider); ok {
+		return sp
+	}
+	return sliceStream{p}
+}
+
+type sliceStream struct {
+	Provider
+}
+
+func (s sliceStream) StreamItems(ctx context.Context) iter.Seq2[Item, error] {
+	return func(yield func(Item, error) bool) {
+		items, err := s.FetchItems(ctx)
+		for _, item := range items {
+			if !yield(item, nil) {
+				return
+			}
+		}
+		if err != nil {
+			yield(Item{}, err)
+		}
+	}
+}
+
+// Collect drains a stream into a slice, so streaming providers can implement FetchItems.
+// Page errors are gathered in a PartialFetchError, any other error is returned as is.
+func Collect(seq iter.Seq2[Item, error]) ([]Item, error) {
+	var (
+		items []Item
+		pages []PageError
+	)
+	for item, err := range seq {
+		if err == nil {
+			items = append(items, item)
+			continue
+		}
+		if page, ok := err.(PageError); ok {
+			pages = append(pages, page)
+			continue
+		}
+		return items, err
+	}
+	return items, NewPartialFetchError(pages, 0)
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNewPartialFetchError(t *testing.T) {
+	if err := NewPartialFetchError(nil, 3); err != nil {
+		t.Errorf("NewPartialFetchError() = %v, want nil when no page failed", err)
+	}
+
+	err := NewPartialFetchError([]PageError{
+		{Page: 3, Err: context.DeadlineExceeded},
+		{Page: 2, Err: errors.New("api status 503")},
+	}, 3)
+
+	want := "2 of 3 pages failed: page 2: api status 503; page 3: context deadline exceeded"
+	if err == nil || err.Error() != want {
+		t.Fatalf("Error() = %v, want %q", err, want)
+	}
+
+	// The causes stay reachable once wrapped by the service
+	wrapped := fmt.Errorf("failed to fetch items: %w", err)
+	if !errors.Is(wrapped, context.DeadlineExceeded) {
+		t.Error("errors.Is() should find the cause of a failed page")
+	}
+	if pages := failedPages(wrapped); len(pages) != 2 || pages[0].Page != 2 {
+		t.Errorf("failedPages() = %v, want pages 2 and 3 in order", pages)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	pageErr := PageError{Page: 2, Err: errors.New("api status 503")}
+	fatalErr := errors.New("connection refused")
+
+	tests := []struct {
+		name      string
+		provider  Provider
+		wantItems int
+		wantPages int
+		wantFatal bool
+	}{
+		{
+			name:      "Complete slice-based scan",
+			provider:  &mockProvider{items: []Item{{ID: "1"}, {ID: "2"}}},
+			wantItems: 2,
+		},
+		{
+			name:      "Partial slice-based scan keeps its items",
+			provider:  &mockProvider{items: []Item{{ID: "1"}}, err: &PartialFetchError{Pages: []PageError{pageErr}}},
+			wantItems: 1,
+			wantPages: 1,
+		},
+		{
+			name:      "Page errors of a stream are gathered",
+			provider:  &mockStreamProvider{yields: []streamYield{{item: Item{ID: "1"}}, {err: pageErr}, {item: Item{ID: "3"}}}},
+			wantItems: 2,
+			wantPages: 1,
+		},
+		{
+			name:      "Other errors end the stream",
+			provider:  &mockStreamProvider{yields: []streamYield{{item: Item{ID: "1"}}, {err: fatalErr}}},
+			wantItems: 1,
+			wantFatal: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := Collect(Stream(tt.provider).StreamItems(context.Background()))
+
+			if len(items) != tt.wantItems {
+				t.Errorf("Collect() returned %d items, want %d", len(items), tt.wantItems)
+			}
+			if pages := failedPages(err); len(pages) != tt.wantPages {
+				t.Errorf("Collect() error %v lists %d failed pages, want %d", err, len(pages), tt.wantPages)
+			}
+			if tt.wantFatal != errors.Is(err, fatalErr) {
+				t.Errorf("Collect() error = %v, fatal error expected: %v", err, tt.wantFatal)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Filter holds declarative rules an item must satisfy to be notified.
+// Zero-valued rules are disabled, so the zero Filter matches everything.
+type Filter struct {
+	MinPrice float64
+	MaxPrice float64
+	Include  []string       // At least one keyword must appear in the title or description
+	Exclude  []string       // No keyword may appear in the title or description
+	Pattern  *regexp.Regexp // Must match the title or description
+	Ranges   []RangeRule
+	Matches  []MatchRule
+}
+
+// RangeRule bounds a numeric attribute, like a surface or a number of rooms (see Item.NumericAttribute).
+// A zero bound is disabled. Items without the attribute do not match.
+type RangeRule struct {
+	Field string
+	Min   float64
+	Max   float64
+}
+
+// MatchRule restricts a textual attribute, like a city or a species (see Item.TextAttribute),
+// to a set of values compared case-insensitively. Items without the attribute do not match.
+type MatchRule struct {
+	Field  string
+	Values []string
+}
+
+// ParseRanges reads range rules written "field:min:max", e.g. "surface:40:,rooms:2:4", an empty bound being disabled.
+func ParseRanges(value string) ([]RangeRule, error) {
+	var rules []RangeRule
+	for _, spec := range strings.Split(value, ",") {
+		if spec = strings.TrimSpace(spec); spec == "" {
+			continue
+		}
+
+		parts := strings.Split(spec, ":")
+		if len(parts) != 3 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid range %q, want field:min:max", spec)
+		}
+
+		minimum, err := parseBound(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid range %q: %w", spec, err)
+		}
+		maximum, err := parseBound(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid range %q: %w", spec, err)
+		}
+
+		if !slices.Contains(NumericAttributes(), parts[0]) {
+			return nil, fmt.Errorf("invalid range %q: unknown field, want one of %v", spec, NumericAttributes())
+		}
+
+		rules = append(rules, RangeRule{Field: parts[0], Min: minimum, Max: maximum})
+	}
+	return rules, nil
+}
+
+func parseBound(bound string) (float64, error) {
+	if bound == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(bound, 64)
+}
+
+// ParseMatches reads match rules written "field:value|value", e.g. "city:Strasbourg|Schiltigheim,furnished:true".
+func ParseMatches(value string) ([]MatchRule, error) {
+	var rules []MatchRule
+	for _, spec := range strings.Split(value, ",") {
+		if spec = strings.TrimSpace(spec); spec == "" {
+			continue
+		}
+
+		field, values, ok := strings.Cut(spec, ":")
+		if !ok || values == "" {
+			return nil, fmt.Errorf("invalid match %q, want field:value|value", spec)
+		}
+		if !slices.Contains(TextAttributes(), field) {
+			return nil, fmt.Errorf("invalid match %q: unknown field, want one of %v", spec, TextAttributes())
+		}
+
+		rules = append(rules, MatchRule{Field: field, Values: strings.Split(values, "|")})
+	}
+	return rules, nil
+}
+
+// Match reports whether the item satisfies every rule.
+// When it does not, the returned reason explains which rule rejected it.
+func (f Filter) Match(item Item) (bool, string) {
+	if f.MinPrice > 0 && item.Price < f.MinPrice {
+		return false, fmt.Sprintf("price %.2f below %.2f", item.Price, f.MinPrice)
+	}
+	if f.MaxPrice > 0 && item.Price > f.MaxPrice {
+		return false, fmt.Sprintf("price %.2f above %.2f", item.Price, f.MaxPrice)
+	}
+
+	// Keywords are matched case-insensitively against the title and the description
+	text := strings.ToLower(item.Title + " " + item.Description)
+
+	if len(f.Include) > 0 && !containsAny(text, f.Include) {
+		return false, "no included keyword"
+	}
+	for _, keyword := range f.Exclude {
+		if strings.Contains(text, strings.ToLower(keyword)) {
+			return false, fmt.Sprintf("excluded keyword %q", keyword)
+		}
+	}
+
+	if f.Pattern != nil && !f.Pattern.MatchString(item.Title) && !f.Pattern.MatchString(item.Description) {
+		return false, fmt.Sprintf("pattern %q not matched", f.Pattern)
+	}
+
+	for _, rule := range f.Ranges {
+		value, ok := item.NumericAttribute(rule.Field)
+		if !ok {
+			return false, fmt.Sprintf("%s unknown", rule.Field)
+		}
+		if rule.Min > 0 && value < rule.Min {
+			return false, fmt.Sprintf("%s %.2f below %.2f", rule.Field, value, rule.Min)
+		}
+		if rule.Max > 0 && value > rule.Max {
+			return false, fmt.Sprintf("%s %.2f above %.2f", rule.Field, value, rule.Max)
+		}
+	}
+
+	for _, rule := range f.Matches {
+		value, ok := item.TextAttribute(rule.Field)
+		if !ok {
+			return false, fmt.Sprintf("%s unknown", rule.Field)
+		}
+		if !equalsAny(value, rule.Values) {
+			return false, fmt.Sprintf("%s %q not accepted", rule.Field, value)
+		}
+	}
+
+	return true, ""
+}
+
+func containsAny(text string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(text, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalsAny(value string, accepted []string) bool {
+	for _, candidate := range accepted {
+		if strings.EqualFold(value, candidate) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestFilter_Match(t *testing.T) {
+	flat := Item{
+		ID:          "123",
+		Title:       "Appartement T2 Schiltigheim",
+		Description: "Bel appartement avec balcon, proche tram",
+		Price:       750,
+		Url:         "https://example.com/t2",
+		RealEstate:  &RealEstateAttributes{Surface: 45, Rooms: 2, City: "Schiltigheim", Rent: 680, Charges: 70},
+	}
+	dog := Item{
+		ID:     "pet-42",
+		Title:  "Rex",
+		Url:    "https://example.com/rex",
+		Animal: &AnimalAttributes{Species: "dog", Sex: SexMale, AgeMonths: 30, Urgent: true},
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		item   Item
+		want   bool
+	}{
+		{"Zero filter matches everything", Filter{}, flat, true},
+		{"Price within bounds", Filter{MinPrice: 450, MaxPrice: 800}, flat, true},
+		{"Price above max", Filter{MaxPrice: 700}, flat, false},
+		{"Price below min", Filter{MinPrice: 800}, flat, false},
+		{"Included keyword is case-insensitive", Filter{Include: []string{"BALCON", "terrasse"}}, flat, true},
+		{"No included keyword", Filter{Include: []string{"terrasse", "jardin"}}, flat, false},
+		{"Excluded keyword", Filter{Exclude: []string{"rez-de-chaussée", "Tram"}}, flat, false},
+		{"Regex match", Filter{Pattern: regexp.MustCompile(`T[2-3]\b`)}, flat, true},
+		{"Regex mismatch", Filter{Pattern: regexp.MustCompile(`T[4-5]\b`)}, flat, false},
+		{"Attribute range", Filter{Ranges: []RangeRule{{Field: "surface", Min: 40}, {Field: "rooms", Min: 2, Max: 3}}}, flat, true},
+		{"Attribute below range", Filter{Ranges: []RangeRule{{Field: "surface", Min: 50}}}, flat, false},
+		{"Charges range", Filter{Ranges: []RangeRule{{Field: "charges", Max: 50}}}, flat, false},
+		{"Unknown attribute does not match", Filter{Ranges: []RangeRule{{Field: "floor", Max: 3}}}, flat, false},
+		{"Attribute of another kind does not match", Filter{Ranges: []RangeRule{{Field: "age_months", Max: 12}}}, flat, false},
+		{"Animal age range", Filter{Ranges: []RangeRule{{Field: "age_months", Min: 12, Max: 36}}}, dog, true},
+		{"Text attribute is case-insensitive", Filter{Matches: []MatchRule{{Field: "city", Values: []string{"strasbourg", "SCHILTIGHEIM"}}}}, flat, true},
+		{"Text attribute not accepted", Filter{Matches: []MatchRule{{Field: "species", Values: []string{"cat"}}}}, dog, false},
+		{"Boolean attribute", Filter{Matches: []MatchRule{{Field: "urgent", Values: []string{"true"}}, {Field: "sex", Values: []string{"male"}}}}, dog, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := tt.filter.Match(tt.item)
+			if got != tt.want {
+				t.Errorf("Filter.Match() = %v (%s), want %v", got, reason, tt.want)
+			}
+			if !got && reason == "" {
+				t.Error("Filter.Match() should explain why an item is rejected")
+			}
+		})
+	}
+}
+
+func TestParseRanges(t *testing.T) {
+	rules, err := ParseRanges("surface:40:, rooms:2:4")
+	if err != nil {
+		t.Fatalf("ParseRanges() returned an unexpected error: %v", err)
+	}
+	if len(rules) != 2 || rules[0] != (RangeRule{Field: "surface", Min: 40}) || rules[1] != (RangeRule{Field: "rooms", Min: 2, Max: 4}) {
+		t.Errorf("ParseRanges() = %+v, want the surface from 40 and 2 to 4 rooms", rules)
+	}
+
+	for _, value := range []string{"surface:40", ":1:2", "surface:big:", "size:1:2"} {
+		if _, err := ParseRanges(value); err == nil {
+			t.Errorf("ParseRanges(%q) should fail", value)
+		}
+	}
+}
+
+func TestParseMatches(t *testing.T) {
+	rules, err := ParseMatches("city:Strasbourg|Schiltigheim")
+	if err != nil {
+		t.Fatalf("ParseMatches() returned an unexpected error: %v", err)
+	}
+	if len(rules) != 1 || rules[0].Field != "city" || len(rules[0].Values) != 2 {
+		t.Errorf("ParseMatches() = %+v, want the two cities", rules)
+	}
+
+	for _, value := range []string{"city", "city:", "colour:black"} {
+		if _, err := ParseMatches(value); err == nil {
+			t.Errorf("ParseMatches(%q) should fail", value)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// DeliveryKind tells which event a delivery announces.
+type DeliveryKind string
+
+const (
+	DeliveryNew     DeliveryKind = "new"
+	DeliveryChanged DeliveryKind = "changed"
+	DeliveryRemoved DeliveryKind = "removed"
+	DeliverySummary DeliveryKind = "summary" // Bootstrap of a provider
+)
+
+// DeliveryStatus is the state of a delivery in the outbox.
+type DeliveryStatus string
+
+const (
+	DeliveryPending DeliveryStatus = "pending"
+	DeliverySent    DeliveryStatus = "sent"
+	DeliveryFailed  DeliveryStatus = "failed" // Dead letter: the retry budget is exhausted
+)
+
+// Delivery is the notification of one event through one channel.
+type Delivery struct {
+	ID            string // Unique per event and channel, deduplicates enqueues
+	Channel       string
+	Kind          DeliveryKind
+	Item          Item             // Summary deliveries only set the provider
+	Previous      Item             // Changed deliveries only
+	Changes       []FieldChange    // Changed deliveries only
+	Summary       BootstrapSummary // Summary deliveries only
+	Status        DeliveryStatus
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	CompletedAt   time.Time // When the delivery was sent or dead-lettered
+}
+
+// Outbox persists notifications until their channel acknowledged them.
+type Outbox interface {
+	// Enqueue stores pending deliveries. A delivery whose ID is already stored is ignored
+	// whatever its status, so enqueueing the same event twice never notifies twice until it is pruned.
+	Enqueue(ctx context.Context, deliveries []Delivery) error
+	// Due returns the pending deliveries whose next attempt is at or before now.
+	Due(ctx context.Context, now time.Time) ([]Delivery, error)
+	// Update stores the outcome of a delivery attempt.
+	Update(ctx context.Context, delivery Delivery) error
+}
+
+// OutboxPruner is implemented by outboxes able to drop the deliveries they no longer need,
+// so that they do not grow forever.
+type OutboxPruner interface {
+	// Prune deletes the sent and dead-lettered deliveries completed before cutoff, returning how many.
+	// Pending deliveries are kept whatever their age.
+	Prune(ctx context.Context, cutoff time.Time) (int, error)
+}
+
+// Channel is a named notification target. Outbox deliveries are tracked per channel,
+// so a failing channel is retried without notifying the others twice.
+// Quiet hours are applied to outbox deliveries only: notifications sent directly are never held.
+type Channel struct {
+	Name     string
+	Notifier Notifier
+	Quiet    QuietHours
+}
+
+// RetryPolicy controls how failed deliveries are retried.
+type RetryPolicy struct {
+	MaxAttempts int           // Attempts before a delivery is dead-lettered
+	BaseDelay   time.Duration // Delay after the first failure, doubled after each new one
+	MaxDelay    time.Duration // Upper bound of the delay between two attempts
+}
+
+// DefaultRetryPolicy retries for about a day before giving up.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 10,
+	BaseDelay:   time.Minute,
+	MaxDelay:    4 * time.Hour,
+}
+
+// Backoff returns the delay before the next attempt of a delivery that failed attempts times.
+func (p RetryPolicy) Backoff(attempts int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempts && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay)
+}
+
+// deliveryID identifies an event for a channel.
+// An item is announced new only once, while updates and removals are identified
+// by the content they announce, as the same item may change several times.
+// Summaries are identified by their bootstrap, as a provider may be seeded again once forced.
+func deliveryID(d Delivery) string {
+	id := d.Channel + "|" + string(d.Kind) + "|" + d.Item.Key().String()
+	switch d.Kind {
+	case DeliveryNew:
+	case DeliverySummary:
+		id += "|" + strconv.FormatInt(d.Summary.At.UnixNano(), 10)
+	default:
+		id += "|" + fingerprint(d.Item)
+	}
+	return id
+}
+
+// fingerprint hashes the fields compared by Diff.
+func fingerprint(item Item) string {
+	h := sha256.New()
+	for _, field := range []string{item.Title, item.Description, formatPrice(item.Price), item.Currency, item.Url} {
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
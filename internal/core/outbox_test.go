@@ -0,0 +1,54 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, BaseDelay: time.Minute, MaxDelay: 10 * time.Minute}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{4, 8 * time.Minute},
+		{5, 10 * time.Minute}, // Capped
+		{50, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := policy.Backoff(tt.attempts); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestDeliveryID(t *testing.T) {
+	item := Item{ID: "1", Provider: "p", Title: "T2", Price: 900, Url: "https://test.com/1"}
+	cheaper := item
+	cheaper.Price = 850
+
+	newID := deliveryID(Delivery{Channel: "email", Kind: DeliveryNew, Item: item})
+	if deliveryID(Delivery{Channel: "email", Kind: DeliveryNew, Item: cheaper}) != newID {
+		t.Error("An item must be announced new only once, whatever its content")
+	}
+	if deliveryID(Delivery{Channel: "log", Kind: DeliveryNew, Item: item}) == newID {
+		t.Error("Deliveries must be tracked per channel")
+	}
+
+	changedID := deliveryID(Delivery{Channel: "email", Kind: DeliveryChanged, Item: item})
+	if deliveryID(Delivery{Channel: "email", Kind: DeliveryChanged, Item: cheaper}) == changedID {
+		t.Error("Successive updates of an item must be distinct deliveries")
+	}
+
+	summary := Delivery{Channel: "email", Kind: DeliverySummary, Item: Item{Provider: "p"}, Summary: BootstrapSummary{Provider: "p", Items: 2, At: time.Now()}}
+	again := summary
+	again.Summary.At = summary.Summary.At.Add(time.Hour)
+	if deliveryID(again) == deliveryID(summary) {
+		t.Error("Successive bootstraps of a provider must be distinct deliveries")
+	}
+}
@@ -1,17 +1,147 @@
 package core
 
-import "context"
+import (
+	"context"
+	"errors"
+	"iter"
+	"time"
+)
 
 type Provider interface {
 	Name() string
 	FetchItems(ctx context.Context) ([]Item, error)
 }
 
+// Enricher is implemented by providers able to complete a listing from its detail page or API,
+// e.g. with its full description, photos, attributes and publication date.
+// The WatcherService only enriches new and changed items, before notifying them.
+// Enrich must keep the identity of the item and the fields compared by Diff.
+type Enricher interface {
+	Enrich(ctx context.Context, item Item) (Item, error)
+}
+
+// StreamProvider is implemented by providers able to yield items while later pages are still downloading.
+// Errors are yielded with a zero Item: a PageError leaves the scan incomplete, while other errors
+// are expected to end the stream. The WatcherService prefers it over FetchItems.
+type StreamProvider interface {
+	Name() string
+	StreamItems(ctx context.Context) iter.Seq2[Item, error]
+}
+
 type Notifier interface {
 	Send(ctx context.Context, item Item) error
 }
 
 type Repository interface {
 	Save(ctx context.Context, item Item) error
-	Exists(ctx context.Context, id string) (bool, error)
+	Exists(ctx context.Context, key ItemKey) (bool, error)
+}
+
+// BatchNotifier is implemented by notifiers able to announce several new items at once,
+// e.g. as a single digest email instead of one email per item.
+type BatchNotifier interface {
+	SendBatch(ctx context.Context, items []Item) error
+}
+
+// NotifyBatch announces new items through n, falling back to one Send per item
+// when the notifier has no batch rendering. Fallback errors are joined together.
+func NotifyBatch(ctx context.Context, n Notifier, items []Item) error {
+	if bn, ok := n.(BatchNotifier); ok {
+		return bn.SendBatch(ctx, items)
+	}
+
+	var errs []error
+	for _, item := range items {
+		if err := n.Send(ctx, item); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SnapshotRepository is implemented by repositories storing the full item on Save.
+// It lets the WatcherService detect listings that changed since they were last seen.
+type SnapshotRepository interface {
+	// Get returns the last saved snapshot of an item and whether the item is known.
+	// Items saved before snapshots were stored are known but come back empty.
+	Get(ctx context.Context, key ItemKey) (Item, bool, error)
+}
+
+// BatchRepository is implemented by repositories able to check and save many items
+// in a single round-trip, instead of one query per item.
+type BatchRepository interface {
+	// ExistsMany returns the subset of keys that are already known.
+	ExistsMany(ctx context.Context, keys []ItemKey) (map[ItemKey]bool, error)
+	// SaveMany saves all items or none of them.
+	SaveMany(ctx context.Context, items []Item) error
+}
+
+// BatchSnapshotRepository is the batch counterpart of SnapshotRepository.
+type BatchSnapshotRepository interface {
+	// GetMany returns the snapshots of the known keys. Unknown keys are absent from the map.
+	GetMany(ctx context.Context, keys []ItemKey) (map[ItemKey]Item, error)
+}
+
+// ItemCounter is implemented by repositories able to tell how many items of a provider they hold.
+// It lets the WatcherService detect a fresh repository and bootstrap it silently.
+type ItemCounter interface {
+	CountItems(ctx context.Context, provider string) (int, error)
+}
+
+// ChangeNotifier is implemented by notifiers able to render an item update.
+type ChangeNotifier interface {
+	SendChange(ctx context.Context, change ItemChange) error
+}
+
+// NotifyChange sends an item update through n, falling back to a plain Send
+// when the notifier has no dedicated rendering for changes.
+func NotifyChange(ctx context.Context, n Notifier, change ItemChange) error {
+	if cn, ok := n.(ChangeNotifier); ok {
+		return cn.SendChange(ctx, change)
+	}
+	return n.Send(ctx, change.Item)
+}
+
+// PresenceTracker is implemented by repositories tracking when items were last observed.
+type PresenceTracker interface {
+	// Reconcile records the items observed by a complete scan of a provider.
+	// Known items of that provider that were not observed get their miss counter increased,
+	// and the ones missing for threshold consecutive scans are marked as removed and returned.
+	// Observed items are restored if they had been marked as removed.
+	Reconcile(ctx context.Context, provider string, seen []ItemKey, threshold int, at time.Time) ([]Item, error)
+}
+
+// RemovalNotifier is implemented by notifiers able to announce a listing that disappeared.
+type RemovalNotifier interface {
+	SendRemoval(ctx context.Context, item Item) error
+}
+
+// NotifyRemoval announces a removed listing through n.
+// Notifiers without a dedicated rendering for removals are skipped.
+func NotifyRemoval(ctx context.Context, n Notifier, item Item) error {
+	if rn, ok := n.(RemovalNotifier); ok {
+		return rn.SendRemoval(ctx, item)
+	}
+	return nil
+}
+
+// BootstrapSummary describes the items a provider was seeded with.
+type BootstrapSummary struct {
+	Provider string
+	Items    int
+	At       time.Time // When the provider was seeded
+}
+
+// SummaryNotifier is implemented by notifiers able to announce a bootstrap.
+type SummaryNotifier interface {
+	SendSummary(ctx context.Context, summary BootstrapSummary) error
+}
+
+// NotifySummary announces a bootstrap through n.
+// Notifiers without a dedicated rendering for summaries are skipped.
+func NotifySummary(ctx context.Context, n Notifier, summary BootstrapSummary) error {
+	if sn, ok := n.(SummaryNotifier); ok {
+		return sn.SendSummary(ctx, summary)
+	}
+	return nil
 }
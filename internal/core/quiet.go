@@ -0,0 +1,100 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// QuietHours is a daily window during which a channel is not notified.
+// Deliveries falling in the window are held in the outbox until it ends, then sent together.
+// The zero value never holds anything.
+type QuietHours struct {
+	Start, End  time.Duration  // Wall clock times since midnight, the window spans midnight when End is before Start
+	Location    *time.Location // Timezone of the window, UTC when nil
+	AllowUrgent bool           // Urgent items are delivered even during the window
+}
+
+// ParseQuietHours reads a window written "22:00-07:00" in the named timezone, e.g. "Europe/Paris".
+// An empty window disables quiet hours, an empty timezone means the local one.
+func ParseQuietHours(window, timezone string) (QuietHours, error) {
+	window = strings.TrimSpace(window)
+	if window == "" {
+		return QuietHours{}, nil
+	}
+
+	from, to, ok := strings.Cut(window, "-")
+	if !ok {
+		return QuietHours{}, fmt.Errorf("invalid quiet hours %q, want HH:MM-HH:MM", window)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return QuietHours{}, fmt.Errorf("invalid quiet hours %q: %w", window, err)
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return QuietHours{}, fmt.Errorf("invalid quiet hours %q: %w", window, err)
+	}
+
+	location := time.Local
+	if timezone != "" {
+		if location, err = time.LoadLocation(timezone); err != nil {
+			return QuietHours{}, fmt.Errorf("invalid quiet hours timezone: %w", err)
+		}
+	}
+
+	return QuietHours{Start: start, End: end, Location: location}, nil
+}
+
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Enabled reports whether the window holds anything.
+func (q QuietHours) Enabled() bool {
+	return q.Start != q.End
+}
+
+// Contains reports whether t falls in the window.
+func (q QuietHours) Contains(t time.Time) bool {
+	if !q.Enabled() {
+		return false
+	}
+	clock := q.clock(t)
+	if q.Start < q.End {
+		return clock >= q.Start && clock < q.End
+	}
+	return clock >= q.Start || clock < q.End
+}
+
+// DeliverAt returns when an item found at now may be notified: now outside the window
+// or for an allowed urgent item, the end of the window otherwise.
+func (q QuietHours) DeliverAt(item Item, now time.Time) time.Time {
+	if !q.Contains(now) || (q.AllowUrgent && item.Urgent()) {
+		return now
+	}
+
+	local := now.In(q.location())
+	day := local.Day()
+	if q.clock(now) >= q.End {
+		day++ // The window ends tomorrow
+	}
+	return time.Date(local.Year(), local.Month(), day, 0, int(q.End/time.Minute), 0, 0, q.location())
+}
+
+// clock returns the wall clock time of t since midnight, in the timezone of the window.
+func (q QuietHours) clock(t time.Time) time.Duration {
+	local := t.In(q.location())
+	return time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute + time.Duration(local.Second())*time.Second
+}
+
+func (q QuietHours) location() *time.Location {
+	if q.Location == nil {
+		return time.UTC
+	}
+	return q.Location
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseQuietHours(t *testing.T) {
+	quiet, err := ParseQuietHours("22:00-07:30", "UTC")
+	if err != nil {
+		t.Fatalf("ParseQuietHours() returned an unexpected error: %v", err)
+	}
+	if quiet.Start != 22*time.Hour || quiet.End != 7*time.Hour+30*time.Minute || quiet.Location != time.UTC {
+		t.Errorf("ParseQuietHours() = %+v, want 22:00-07:30 UTC", quiet)
+	}
+
+	if quiet, err := ParseQuietHours("", "UTC"); err != nil || quiet.Enabled() {
+		t.Errorf("ParseQuietHours(\"\") = %+v, %v; want disabled", quiet, err)
+	}
+
+	for _, window := range []string{"22:00", "22h-7h", "25:00-07:00"} {
+		if _, err := ParseQuietHours(window, "UTC"); err == nil {
+			t.Errorf("ParseQuietHours(%q) should fail", window)
+		}
+	}
+	if _, err := ParseQuietHours("22:00-07:00", "Mars/Olympus"); err == nil {
+		t.Error("ParseQuietHours() should reject an unknown timezone")
+	}
+}
+
+func TestQuietHours_DeliverAt(t *testing.T) {
+	paris := time.FixedZone("CET", 3600)
+	night := QuietHours{Start: 22 * time.Hour, End: 7 * time.Hour, Location: paris}
+	lunch := QuietHours{Start: 12 * time.Hour, End: 14 * time.Hour, Location: paris, AllowUrgent: true}
+
+	item := Item{ID: "1", Title: "Rex"}
+	urgent := Item{ID: "2", Title: "Rex", Animal: &AnimalAttributes{Urgent: true}}
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, time.March, day, hour, minute, 0, 0, paris)
+	}
+
+	tests := []struct {
+		name  string
+		quiet QuietHours
+		item  Item
+		now   time.Time
+		want  time.Time
+	}{
+		{"Disabled", QuietHours{}, item, at(10, 3, 0), at(10, 3, 0)},
+		{"Before the window", night, item, at(10, 21, 59), at(10, 21, 59)},
+		{"Late evening waits for tomorrow morning", night, item, at(10, 23, 15), at(11, 7, 0)},
+		{"Early morning waits for the same morning", night, item, at(11, 6, 59), at(11, 7, 0)},
+		{"Window end is not quiet", night, item, at(11, 7, 0), at(11, 7, 0)},
+		{"Urgent item held when not allowed", night, urgent, at(10, 23, 15), at(11, 7, 0)},
+		{"Daytime window", lunch, item, at(10, 12, 30), at(10, 14, 0)},
+		{"Urgent item allowed", lunch, urgent, at(10, 12, 30), at(10, 12, 30)},
+		{"Window in another timezone", night, item, time.Date(2024, time.March, 10, 21, 30, 0, 0, time.UTC), at(11, 7, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.quiet.DeliverAt(tt.item, tt.now); !got.Equal(tt.want) {
+				t.Errorf("DeliverAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
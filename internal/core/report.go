@@ -0,0 +1,104 @@
+package core
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// ItemError records why an item could not be fully processed.
+type ItemError struct {
+	Key   ItemKey
+	Stage string // "lookup", "notify", "save"...
+	Err   error
+}
+
+// ProviderReport describes the outcome of one provider during a run.
+type ProviderReport struct {
+	Provider   string
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Duration   time.Duration
+
+	Fetched     int // Items returned by the provider
+	Invalid     int // Items skipped because they are incomplete
+	AlreadySeen int // Items already known by the repository
+	Filtered    int // New items rejected by the filter, saved without notification
+	Notified    int // New items notified, or enqueued when an outbox is configured
+	Changed     int // Known items whose update was detected and handled
+	Enriched    int // New or changed items completed from their detail page
+	Removed     int // Known items marked as removed
+	Saved       int // Items written to the repository
+	Failed      int // Items that hit an error, see Errors
+	FailedPages int // Pages the provider failed to fetch, making the scan incomplete
+
+	Errors []ItemError
+
+	// Bootstrapped is set when the items were saved as seen without notification.
+	Bootstrapped bool
+
+	// Degraded is set when the provider returned partial results along with an error,
+	// typically a PartialFetchError. Decisions requiring a complete scan are then skipped.
+	Degraded bool
+	// Err is the provider error. Nothing was processed when it is set and Degraded is not.
+	Err error
+}
+
+func (r *ProviderReport) fail(key ItemKey, stage string, err error) {
+	r.Failed++
+	r.Errors = append(r.Errors, ItemError{Key: key, Stage: stage, Err: err})
+}
+
+// LogValue implements slog.LogValuer so reports can be logged as a single attribute.
+func (r ProviderReport) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("provider", r.Provider),
+		slog.Duration("duration", r.Duration),
+		slog.Int("fetched", r.Fetched),
+		slog.Int("invalid", r.Invalid),
+		slog.Int("already_seen", r.AlreadySeen),
+		slog.Int("filtered", r.Filtered),
+		slog.Int("notified", r.Notified),
+		slog.Int("changed", r.Changed),
+		slog.Int("enriched", r.Enriched),
+		slog.Int("removed", r.Removed),
+		slog.Int("saved", r.Saved),
+		slog.Int("failed", r.Failed),
+		slog.Int("failed_pages", r.FailedPages),
+		slog.Bool("degraded", r.Degraded),
+		slog.Bool("bootstrapped", r.Bootstrapped),
+	}
+	if r.Err != nil {
+		attrs = append(attrs, slog.String("error", r.Err.Error()))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// RunReport gathers the outcome of a WatcherService run.
+type RunReport struct {
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Duration   time.Duration
+	Providers  []ProviderReport
+}
+
+// Err joins the errors of the providers that failed.
+func (r RunReport) Err() error {
+	var errs []error
+	for _, p := range r.Providers {
+		if p.Err != nil {
+			errs = append(errs, p.Err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Degraded reports whether at least one provider returned partial results.
+func (r RunReport) Degraded() bool {
+	for _, p := range r.Providers {
+		if p.Degraded {
+			return true
+		}
+	}
+	return false
+}
@@ -2,77 +2,1063 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
+	"time"
 )
 
-// WatcherService orchestrates the data flow between the Provider, Repository and Notifier.
+// defaultConcurrency is the number of providers fetched in parallel when no budget is configured.
+const defaultConcurrency = 2
+
+// defaultEnrichConcurrency is the number of detail pages fetched in parallel for a provider.
+const defaultEnrichConcurrency = 4
+
+// defaultChunkSize is the number of streamed items looked up and saved together.
+const defaultChunkSize = 100
+
+// ChangePolicy selects which updates of known listings trigger a notification.
+type ChangePolicy int
+
+const (
+	NotifyAllChanges ChangePolicy = iota
+	NotifyPriceDrops
+	IgnoreChanges
+)
+
+// ParseChangePolicy converts a configuration value ("all", "price-drop", "off") to a ChangePolicy.
+func ParseChangePolicy(value string) (ChangePolicy, error) {
+	switch value {
+	case "", "all":
+		return NotifyAllChanges, nil
+	case "price-drop":
+		return NotifyPriceDrops, nil
+	case "off":
+		return IgnoreChanges, nil
+	}
+	return 0, fmt.Errorf("unknown change policy %q", value)
+}
+
+// BootstrapMode selects when a provider is seeded: its items are saved as seen without notification.
+type BootstrapMode int
+
+const (
+	BootstrapOff    BootstrapMode = iota // Never: every item of a fresh repository is notified
+	BootstrapAuto                        // When the repository holds no item of the provider
+	BootstrapForced                      // On the next run of every provider, then as BootstrapAuto
+)
+
+// ParseBootstrapMode converts a configuration value ("off", "auto", "force") to a BootstrapMode.
+func ParseBootstrapMode(value string) (BootstrapMode, error) {
+	switch value {
+	case "", "off":
+		return BootstrapOff, nil
+	case "auto":
+		return BootstrapAuto, nil
+	case "force":
+		return BootstrapForced, nil
+	}
+	return 0, fmt.Errorf("unknown bootstrap mode %q", value)
+}
+
+// WatcherService orchestrates the data flow between the Providers, Repository and Notifier.
 type WatcherService struct {
-	provider Provider
-	repo     Repository
-	notifier Notifier
-	logger   *slog.Logger
+	providers    []Provider
+	repo         Repository
+	snapshots    SnapshotRepository      // nil when the repository does not store snapshots
+	presence     PresenceTracker         // nil when the repository does not track presence
+	batch        BatchRepository         // nil when the repository only works item by item
+	batchGets    BatchSnapshotRepository // nil when snapshots are only read item by item
+	counter      ItemCounter             // nil when the repository cannot count items
+	notifier     Notifier
+	logger       *slog.Logger
+	concurrency  int
+	sem          chan struct{} // Provider slots shared by concurrent runs
+	chunkSize    int
+	enrichConc   int
+	changePolicy ChangePolicy
+	filters      map[string]Filter // Keyed by provider name
+
+	// Removal detection, disabled when removalThreshold is 0
+	removalThreshold int
+	notifyRemovals   bool
+
+	// Bootstrap: forced holds the providers still waiting for their forced bootstrap
+	bootstrap        BootstrapMode
+	bootstrapSummary bool
+	mu               sync.Mutex
+	forced           map[string]bool
+
+	// Notification routing, a single default subscription when none is configured
+	subscriptions []Subscription
+
+	// Persisted notifications, disabled when outbox is nil
+	outbox    Outbox
+	retry     RetryPolicy
+	retention time.Duration // Age of the completed deliveries pruned, kept forever when 0
+	channels  []Channel     // Channels of the default subscription
+	deliverMu sync.Mutex    // Concurrent runs must not send the same due deliveries
+}
+
+// Option customizes a WatcherService at construction time.
+type Option func(*WatcherService)
+
+// WithConcurrency sets how many providers may be fetched at the same time.
+// Values lower than 1 are ignored.
+func WithConcurrency(n int) Option {
+	return func(s *WatcherService) {
+		if n > 0 {
+			s.concurrency = n
+		}
+	}
+}
+
+// WithChunkSize sets how many streamed items are processed together.
+// Smaller chunks notify sooner, larger ones need fewer repository round-trips. Values lower than 1 are ignored.
+func WithChunkSize(n int) Option {
+	return func(s *WatcherService) {
+		if n > 0 {
+			s.chunkSize = n
+		}
+	}
+}
+
+// WithEnrichConcurrency sets how many items of a provider may be enriched at the same time.
+// Values lower than 1 are ignored.
+func WithEnrichConcurrency(n int) Option {
+	return func(s *WatcherService) {
+		if n > 0 {
+			s.enrichConc = n
+		}
+	}
+}
+
+// WithChangePolicy selects which listing updates are notified.
+// Updates are only detected when the repository implements SnapshotRepository.
+func WithChangePolicy(p ChangePolicy) Option {
+	return func(s *WatcherService) {
+		s.changePolicy = p
+	}
+}
+
+// WithRemovalDetection marks items as removed once they are missing from threshold
+// consecutive complete scans of their provider, optionally notifying "listing gone".
+// It requires a repository implementing PresenceTracker.
+func WithRemovalDetection(threshold int, notify bool) Option {
+	return func(s *WatcherService) {
+		if threshold > 0 {
+			s.removalThreshold = threshold
+			s.notifyRemovals = notify
+		}
+	}
+}
+
+// WithFilters sets the rules items must satisfy to be notified, keyed by provider name.
+// Items rejected by their provider's filter are still saved so they are not re-evaluated.
+func WithFilters(filters map[string]Filter) Option {
+	return func(s *WatcherService) {
+		s.filters = filters
+	}
+}
+
+// WithOutbox persists notifications in an outbox before marking items as seen,
+// then delivers them through each channel with retries and exponential backoff.
+// The channels are those of the default subscription, used when no subscription is configured.
+// Without channels, the service notifier is used as a single "default" channel.
+// Zero fields of the policy fall back to DefaultRetryPolicy.
+func WithOutbox(o Outbox, policy RetryPolicy, channels ...Channel) Option {
+	return func(s *WatcherService) {
+		if policy.MaxAttempts <= 0 {
+			policy.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+		}
+		if policy.BaseDelay <= 0 {
+			policy.BaseDelay = DefaultRetryPolicy.BaseDelay
+		}
+		if policy.MaxDelay <= 0 {
+			policy.MaxDelay = DefaultRetryPolicy.MaxDelay
+		}
+
+		s.outbox = o
+		s.retry = policy
+		s.channels = channels
+	}
+}
+
+// WithOutboxRetention prunes the sent and dead-lettered deliveries completed more than retention ago,
+// when the outbox implements OutboxPruner. A pruned delivery no longer deduplicates its event,
+// so retention must outlast the runs that may enqueue it again, like those following a failed save.
+// Deliveries are kept forever when retention is 0, the default.
+func WithOutboxRetention(retention time.Duration) Option {
+	return func(s *WatcherService) {
+		s.retention = retention
+	}
+}
+
+// WithSubscriptions routes each new or updated item to the channels of every subscription it matches.
+// Items matching no subscription are saved without notification. Subscription names must be unique.
+// Without subscriptions, every item is notified through the service notifier, or the outbox channels.
+func WithSubscriptions(subscriptions ...Subscription) Option {
+	return func(s *WatcherService) {
+		s.subscriptions = subscriptions
+	}
+}
+
+// WithBootstrap seeds providers without notifying their items, according to mode.
+// Automatic detection of a fresh repository requires a repository implementing ItemCounter.
+// When summary is set, a single summary is notified per bootstrapped provider,
+// through the channels of the subscriptions watching it.
+func WithBootstrap(mode BootstrapMode, summary bool) Option {
+	return func(s *WatcherService) {
+		s.bootstrap = mode
+		s.bootstrapSummary = summary
+	}
 }
 
 // NewWatcherService creates a new service instance with injected dependencies.
-func NewWatcherService(p Provider, r Repository, n Notifier, l *slog.Logger) *WatcherService {
-	return &WatcherService{
-		provider: p,
-		repo:     r,
-		notifier: n,
-		logger:   l,
+func NewWatcherService(providers []Provider, r Repository, n Notifier, l *slog.Logger, opts ...Option) *WatcherService {
+	s := &WatcherService{
+		providers:   providers,
+		repo:        r,
+		notifier:    n,
+		logger:      l,
+		concurrency: defaultConcurrency,
+		chunkSize:   defaultChunkSize,
+		enrichConc:  defaultEnrichConcurrency,
+	}
+	if snapshots, ok := r.(SnapshotRepository); ok {
+		s.snapshots = snapshots
+	}
+	if presence, ok := r.(PresenceTracker); ok {
+		s.presence = presence
 	}
+	if batch, ok := r.(BatchRepository); ok {
+		s.batch = batch
+	}
+	if batchGets, ok := r.(BatchSnapshotRepository); ok {
+		s.batchGets = batchGets
+	}
+	if counter, ok := r.(ItemCounter); ok {
+		s.counter = counter
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.sem = make(chan struct{}, s.concurrency)
+	if len(s.channels) == 0 {
+		s.channels = []Channel{{Name: "default", Notifier: n}}
+	}
+	if len(s.subscriptions) == 0 {
+		s.subscriptions = []Subscription{{Channels: s.channels}}
+	}
+	if s.bootstrap == BootstrapForced {
+		s.forced = make(map[string]bool, len(providers))
+		for _, p := range providers {
+			s.forced[p.Name()] = true
+		}
+	}
+	return s
+}
+
+// Run executes the main logic for every provider: fetch, filter, notify, and persist.
+// Providers run concurrently within the configured budget. A failing provider does not
+// abort the others: the report describes each provider outcome separately, and the
+// returned error joins all provider failures.
+// When an outbox is configured, due deliveries are attempted once all providers are done.
+func (s *WatcherService) Run(ctx context.Context) (RunReport, error) {
+	return s.run(ctx, s.providers)
 }
 
-// Run executes the main logic: fetch, filter, notify, and persist.
-func (s *WatcherService) Run(ctx context.Context) error {
-	s.logger.Info("starting watcher run", "provider", s.provider.Name())
+// RunProvider runs a single provider by name, for providers scheduled separately.
+// It is safe to call concurrently for different providers, which share the concurrency budget.
+func (s *WatcherService) RunProvider(ctx context.Context, name string) (RunReport, error) {
+	for _, p := range s.providers {
+		if p.Name() == name {
+			return s.run(ctx, []Provider{p})
+		}
+	}
+	return RunReport{}, fmt.Errorf("unknown provider %q", name)
+}
+
+func (s *WatcherService) run(ctx context.Context, providers []Provider) (RunReport, error) {
+	report := RunReport{
+		StartedAt: time.Now(),
+		Providers: make([]ProviderReport, len(providers)),
+	}
+
+	var wg sync.WaitGroup
+
+	for i, p := range providers {
+		wg.Add(1)
+		go func(i int, p Provider) {
+			defer wg.Done()
+
+			s.sem <- struct{}{}
+			defer func() { <-s.sem }()
+
+			// Each goroutine owns its slot: no locking needed
+			report.Providers[i] = s.runProvider(ctx, p)
+		}(i, p)
+	}
 
-	// 1. Fetch
-	items, err := s.provider.FetchItems(ctx)
+	wg.Wait()
+
+	s.deliver(ctx)
+
+	report.FinishedAt = time.Now()
+	report.Duration = report.FinishedAt.Sub(report.StartedAt)
+	return report, report.Err()
+}
+
+// runProvider processes the items of a single provider.
+func (s *WatcherService) runProvider(ctx context.Context, p Provider) ProviderReport {
+	logger := s.logger.With("provider", p.Name())
+	logger.Info("starting watcher run")
+
+	report := ProviderReport{Provider: p.Name(), StartedAt: time.Now()}
+	defer func() {
+		report.FinishedAt = time.Now()
+		report.Duration = report.FinishedAt.Sub(report.StartedAt)
+		logger.Info("watcher run finished", "report", report)
+	}()
+
+	filter := s.filters[p.Name()]
+	enricher, _ := p.(Enricher)
+
+	// Seeding is decided upfront: bootstrapped items are only saved once the scan is known to be complete
+	bootstrap, err := s.shouldBootstrap(ctx, p.Name())
 	if err != nil {
-		return fmt.Errorf("failed to fetch items from %s: %w", s.provider.Name(), err)
+		// Notifying everything is worse than missing one run: retry on the next one
+		report.Err = fmt.Errorf("failed to count items of %s, run skipped: %w", p.Name(), err)
+		logger.Error("provider run failed", "error", report.Err)
+		return report
 	}
 
-	s.logger.Info("items fetched", "count", len(items))
+	// Digests are sent once per run: new items are then held back until the end of the stream
+	holdNew := s.outbox == nil && s.digests()
+
+	var (
+		seen      []ItemKey // Keys observed by the scan, for removal detection
+		chunk     []Item    // Items waiting to be processed
+		held      []Item    // New items waiting for the digest
+		fetchErrs []error
+	)
+
+	// 1. Fetch: items are processed by chunks while later pages are still downloading
+	for item, err := range Stream(p).StreamItems(ctx) {
+		if err != nil {
+			fetchErrs = append(fetchErrs, err)
+			continue
+		}
+		report.Fetched++
+
+		// Scope the item identity to its source
+		item.Provider = p.Name()
 
-	newCount := 0
-	for _, item := range items {
 		// Defensive check
 		if !item.IsValid() {
-			s.logger.Warn("skipping invalid item", "item", item)
+			logger.Warn("skipping invalid item", "item", item)
+			report.Invalid++
 			continue
 		}
+		seen = append(seen, item.Key())
+		chunk = append(chunk, item)
 
-		// Check Dedup (Idempotency)
-		exists, err := s.repo.Exists(ctx, item.ID)
-		if err != nil {
-			s.logger.Error("failed to check existence", "id", item.ID, "error", err)
+		if !bootstrap && len(chunk) >= s.chunkSize {
+			held = append(held, s.processChunk(ctx, logger, &report, filter, enricher, chunk, holdNew)...)
+			chunk = nil
+		}
+	}
+
+	if len(fetchErrs) > 0 {
+		report.Err = fmt.Errorf("failed to fetch items from %s: %w", p.Name(), errors.Join(fetchErrs...))
+		if report.Fetched == 0 {
+			logger.Error("provider run failed", "error", report.Err)
+			return report
+		}
+		// Partial results: process what we got, but the scan is not complete
+		report.Degraded = true
+		logger.Warn("provider returned partial results", "error", report.Err)
+
+		pages := failedPages(report.Err)
+		report.FailedPages = len(pages)
+		for _, page := range pages {
+			logger.Warn("failed to fetch page", "page", page.Page, "error", page.Err)
+		}
+	}
+
+	logger.Info("items fetched", "count", report.Fetched)
+
+	if bootstrap {
+		// Seeding part of the listings would notify the missing ones on the next run
+		if report.Degraded {
+			logger.Warn("incomplete scan, bootstrap postponed to the next run")
+			return report
+		}
+		s.seed(ctx, logger, &report, chunk)
+		s.detectRemovals(ctx, logger, &report, seen)
+		return report
+	}
+
+	held = append(held, s.processChunk(ctx, logger, &report, filter, enricher, chunk, holdNew)...)
+	s.saveAll(ctx, logger, &report, s.notifyNew(ctx, logger, &report, held))
+
+	// Only a complete scan tells which listings disappeared
+	if !report.Degraded {
+		s.detectRemovals(ctx, logger, &report, seen)
+	}
+
+	return report
+}
+
+// digests reports whether at least one channel sends new items as a digest.
+func (s *WatcherService) digests() bool {
+	for _, t := range s.allTargets() {
+		if _, ok := t.channel.Notifier.(BatchNotifier); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// processChunk dedups a chunk of valid items, handles the known ones and notifies the new ones.
+// When hold is set, new items are returned instead of being notified, and saved by the caller once notified.
+func (s *WatcherService) processChunk(ctx context.Context, logger *slog.Logger, report *ProviderReport, filter Filter, enricher Enricher, chunk []Item, hold bool) []Item {
+	if len(chunk) == 0 {
+		return nil
+	}
+
+	keys := make([]ItemKey, len(chunk))
+	for i, item := range chunk {
+		keys[i] = item.Key()
+	}
+
+	// Check Dedup (Idempotency) for the whole chunk
+	known, lookupErrs := s.lookupAll(ctx, keys)
+
+	// Enrichment: detail pages are only fetched for new and changed listings, before filtering
+	if enricher != nil {
+		s.enrichAll(ctx, logger, report, enricher, chunk, func(item Item) bool {
+			if _, failed := lookupErrs[item.Key()]; failed {
+				return false
+			}
+			previous, ok := known[item.Key()]
+			if !ok {
+				return true
+			}
+			return s.snapshots != nil && previous.IsValid() && len(Diff(previous, item)) > 0
+		})
+	}
+
+	var (
+		fresh  []Item // New items to notify
+		toSave []Item // Items to persist once the chunk is processed
+	)
+	for _, item := range chunk {
+		if err, failed := lookupErrs[item.Key()]; failed {
+			logger.Error("failed to check existence", "id", item.ID, "error", err)
+			report.fail(item.Key(), "lookup", err)
 			continue // Don't block the batch on single failure
 		}
 
-		if exists {
+		if previous, ok := known[item.Key()]; ok {
+			report.AlreadySeen++
+			if s.handleKnown(ctx, logger, report, filter, item, previous) {
+				toSave = append(toSave, item)
+			}
+			continue
+		}
+
+		// Filter: rejected items are marked as seen without notification
+		if ok, reason := filter.Match(item); !ok {
+			logger.Debug("item filtered out", "id", item.ID, "reason", reason)
+			report.Filtered++
+			toSave = append(toSave, item)
+			continue
+		}
+		if len(s.targets(item)) == 0 {
+			logger.Debug("item filtered out", "id", item.ID, "reason", "no matching subscription")
+			report.Filtered++
+			toSave = append(toSave, item)
+			continue
+		}
+
+		logger.Info("new item found", "id", item.ID, "title", item.Title)
+		fresh = append(fresh, item)
+	}
+
+	if hold {
+		s.saveAll(ctx, logger, report, toSave)
+		return fresh
+	}
+
+	toSave = append(toSave, s.notifyNew(ctx, logger, report, fresh)...)
+	s.saveAll(ctx, logger, report, toSave)
+	return nil
+}
+
+// enrichAll replaces, in place, the items selected by needed with their enriched version.
+// Enrichment is best effort: an item that cannot be enriched is processed as listed.
+func (s *WatcherService) enrichAll(ctx context.Context, logger *slog.Logger, report *ProviderReport, enricher Enricher, items []Item, needed func(Item) bool) {
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		sem = make(chan struct{}, s.enrichConc)
+	)
+
+	for i := range items {
+		if !needed(items[i]) {
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			enriched, err := enricher.Enrich(ctx, items[i])
+			if err != nil {
+				logger.Warn("failed to enrich item, keeping the listed version", "id", items[i].ID, "error", err)
+				return
+			}
+
+			// The identity is owned by the service
+			enriched.ID, enriched.Provider = items[i].ID, items[i].Provider
+			items[i] = enriched
+
+			mu.Lock()
+			report.Enriched++
+			mu.Unlock()
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+// shouldBootstrap tells whether the items of a provider must be seeded without notification.
+// A forced bootstrap is consumed by the first seed of each provider.
+// It fails when the items cannot be counted, as the provider must then not be run at all.
+func (s *WatcherService) shouldBootstrap(ctx context.Context, provider string) (bool, error) {
+	s.mu.Lock()
+	forced := s.forced[provider]
+	s.mu.Unlock()
+
+	if forced {
+		return true, nil
+	}
+	if s.bootstrap == BootstrapOff || s.counter == nil {
+		return false, nil
+	}
+
+	count, err := s.counter.CountItems(ctx, provider)
+	if err != nil {
+		return false, err
+	}
+	return count == 0, nil
+}
+
+// seed saves all items as seen without notifying them, then sends at most a summary.
+func (s *WatcherService) seed(ctx context.Context, logger *slog.Logger, report *ProviderReport, items []Item) {
+	logger.Info("bootstrapping provider, items are saved without notification", "count", len(items))
+	report.Bootstrapped = true
+
+	s.mu.Lock()
+	delete(s.forced, report.Provider)
+	s.mu.Unlock()
+
+	s.saveAll(ctx, logger, report, items)
+
+	if !s.bootstrapSummary || report.Saved == 0 {
+		return
+	}
+	summary := BootstrapSummary{Provider: report.Provider, Items: report.Saved, At: report.StartedAt}
+	if err := s.announceSummary(ctx, summary); err != nil {
+		logger.Error("failed to notify bootstrap summary", "error", err)
+	}
+}
+
+// detectRemovals reconciles the keys observed by a complete scan with the repository
+// and announces the listings that disappeared.
+func (s *WatcherService) detectRemovals(ctx context.Context, logger *slog.Logger, report *ProviderReport, seen []ItemKey) {
+	if s.removalThreshold == 0 || s.presence == nil {
+		return
+	}
+
+	// An empty scan is more likely a broken page than every listing vanishing at once
+	if len(seen) == 0 {
+		logger.Warn("skipping removal detection on empty scan")
+		return
+	}
+
+	removed, err := s.presence.Reconcile(ctx, report.Provider, seen, s.removalThreshold, time.Now())
+	if err != nil {
+		logger.Error("failed to reconcile seen items", "error", err)
+		return
+	}
+
+	report.Removed = len(removed)
+	for _, item := range removed {
+		logger.Info("item removed", "id", item.ID, "title", item.Title)
+
+		if !s.notifyRemovals {
+			continue
+		}
+		if err := s.announceRemoval(ctx, item); err != nil {
+			logger.Error("failed to notify removal", "id", item.ID, "error", err)
+			report.fail(item.Key(), "notify", err)
+		}
+	}
+}
+
+// notifyNew announces the new items of a run and returns the ones that can be saved.
+// Notifiers implementing BatchNotifier receive a single batch, others one Send per item.
+func (s *WatcherService) notifyNew(ctx context.Context, logger *slog.Logger, report *ProviderReport, items []Item) []Item {
+	if len(items) == 0 {
+		return nil
+	}
+
+	// Outbox: persist the notifications first. Saving may then fail without losing them,
+	// and enqueueing again on the next run is deduplicated by the outbox.
+	if s.outbox != nil {
+		events := make([]Delivery, 0, len(items))
+		for _, item := range items {
+			events = append(events, Delivery{Kind: DeliveryNew, Item: item})
+		}
+		if err := s.enqueue(ctx, events...); err != nil {
+			logger.Error("failed to enqueue notifications", "count", len(items), "error", err)
+			for _, item := range items {
+				report.fail(item.Key(), "enqueue", err)
+			}
+			return nil
+		}
+		report.Notified += len(items)
+		return items
+	}
+
+	// Strategy: If notification fails, do not save the ID.
+	// We want to retry this item on the next run (At-Least-Once delivery).
+	failed := make(map[ItemKey]error)
+	for _, r := range s.routes(items) {
+		for key, err := range sendNew(ctx, r.channel.Notifier, r.items) {
+			logger.Error("failed to notify", "id", key.ID, "channel", r.key, "error", err)
+			failed[key] = errors.Join(failed[key], fmt.Errorf("%s: %w", r.key, err))
+		}
+	}
+
+	var notified []Item
+	for _, item := range items {
+		if err, ok := failed[item.Key()]; ok {
+			report.fail(item.Key(), "notify", err)
 			continue
 		}
+		notified = append(notified, item)
+	}
+	report.Notified += len(notified)
+	return notified
+}
+
+// sendNew notifies new items through a notifier, as a single batch when it supports it,
+// and returns the error of each item that could not be notified.
+func sendNew(ctx context.Context, n Notifier, items []Item) map[ItemKey]error {
+	errs := make(map[ItemKey]error)
+	if bn, ok := n.(BatchNotifier); ok {
+		if err := bn.SendBatch(ctx, items); err != nil {
+			for _, item := range items {
+				errs[item.Key()] = err
+			}
+		}
+		return errs
+	}
+	for _, item := range items {
+		if err := n.Send(ctx, item); err != nil {
+			errs[item.Key()] = err
+		}
+	}
+	return errs
+}
+
+// targets returns the channels of every subscription matching the item.
+func (s *WatcherService) targets(item Item) []target {
+	return s.targetsWhere(func(sub Subscription) bool { return sub.Matches(item) })
+}
 
-		s.logger.Info("new item found", "id", item.ID, "title", item.Title)
+// summaryTargets returns the channels of every subscription watching the provider:
+// a bootstrap summary describes all its items, whatever the filters.
+func (s *WatcherService) summaryTargets(provider string) []target {
+	return s.targetsWhere(func(sub Subscription) bool { return sub.Watches(provider) })
+}
 
-		// Notify
-		if err := s.notifier.Send(ctx, item); err != nil {
-			s.logger.Error("failed to notify", "id", item.ID, "error", err)
-			// Strategy: If notification fails, do not save the ID.
-			// We want to retry this item on the next run (At-Least-Once delivery).
+// targetsWhere returns the channels of every subscription selected by match.
+func (s *WatcherService) targetsWhere(match func(Subscription) bool) []target {
+	var targets []target
+	for _, sub := range s.subscriptions {
+		if !match(sub) {
 			continue
 		}
+		for _, ch := range sub.Channels {
+			targets = append(targets, target{key: channelKey(sub.Name, ch.Name), channel: ch})
+		}
+	}
+	return targets
+}
+
+// route is a channel of a subscription along with the items routed to it.
+type route struct {
+	target
+	items []Item
+}
+
+// routes groups items by the channels they are routed to, in subscription order.
+func (s *WatcherService) routes(items []Item) []route {
+	var routes []route
+	index := make(map[string]int)
+	for _, item := range items {
+		for _, t := range s.targets(item) {
+			i, ok := index[t.key]
+			if !ok {
+				i = len(routes)
+				index[t.key] = i
+				routes = append(routes, route{target: t})
+			}
+			routes[i].items = append(routes[i].items, item)
+		}
+	}
+	return routes
+}
+
+// saveAll persists items in a single call when the repository supports it,
+// one by one otherwise, recording failures in the report.
+func (s *WatcherService) saveAll(ctx context.Context, logger *slog.Logger, report *ProviderReport, items []Item) {
+	if len(items) == 0 {
+		return
+	}
+
+	if s.batch != nil {
+		if err := s.batch.SaveMany(ctx, items); err != nil {
+			logger.Error("failed to save items", "count", len(items), "error", err)
+			for _, item := range items {
+				report.fail(item.Key(), "save", err)
+			}
+			return
+		}
+		report.Saved += len(items)
+		return
+	}
 
-		// Save
+	for _, item := range items {
 		if err := s.repo.Save(ctx, item); err != nil {
-			s.logger.Error("failed to save id", "id", item.ID, "error", err)
-		} else {
-			newCount++
+			logger.Error("failed to save id", "id", item.ID, "error", err)
+			report.fail(item.Key(), "save", err)
+			continue
 		}
+		report.Saved++
 	}
+}
 
-	s.logger.Info("watcher run finished", "new_items", newCount)
-	return nil
+// lookupAll returns the stored snapshots of the known keys, using a single query when the
+// repository supports it. Snapshots are empty when the repository does not store them.
+// Keys that could not be checked are returned with their error.
+func (s *WatcherService) lookupAll(ctx context.Context, keys []ItemKey) (map[ItemKey]Item, map[ItemKey]error) {
+	known := make(map[ItemKey]Item)
+	failed := make(map[ItemKey]error)
+	if len(keys) == 0 {
+		return known, failed
+	}
+
+	failAll := func(err error) (map[ItemKey]Item, map[ItemKey]error) {
+		for _, key := range keys {
+			failed[key] = err
+		}
+		return known, failed
+	}
+
+	switch {
+	case s.snapshots != nil && s.batchGets != nil:
+		snapshots, err := s.batchGets.GetMany(ctx, keys)
+		if err != nil {
+			return failAll(err)
+		}
+		return snapshots, failed
+
+	case s.snapshots == nil && s.batch != nil:
+		exists, err := s.batch.ExistsMany(ctx, keys)
+		if err != nil {
+			return failAll(err)
+		}
+		for key, ok := range exists {
+			if ok {
+				known[key] = Item{}
+			}
+		}
+		return known, failed
+	}
+
+	for _, key := range keys {
+		previous, ok, err := s.lookup(ctx, key)
+		switch {
+		case err != nil:
+			failed[key] = err
+		case ok:
+			known[key] = previous
+		}
+	}
+	return known, failed
+}
+
+// lookup returns the stored snapshot of an item, when available, and whether the item is known.
+func (s *WatcherService) lookup(ctx context.Context, key ItemKey) (Item, bool, error) {
+	if s.snapshots != nil {
+		return s.snapshots.Get(ctx, key)
+	}
+	known, err := s.repo.Exists(ctx, key)
+	return Item{}, known, err
+}
+
+// handleKnown compares an already seen item with its stored snapshot.
+// It notifies the update according to the change policy and the filter,
+// and reports whether the new snapshot must be saved.
+func (s *WatcherService) handleKnown(ctx context.Context, logger *slog.Logger, report *ProviderReport, filter Filter, item, previous Item) bool {
+	if s.snapshots == nil {
+		return false
+	}
+
+	// Items saved before snapshots existed have no baseline: record one silently
+	if !previous.IsValid() {
+		return true
+	}
+
+	changes := Diff(previous, item)
+	if len(changes) == 0 {
+		return false
+	}
+
+	change := ItemChange{Item: item, Previous: previous, Changes: changes}
+	logger.Info("item changed", "id", item.ID, "title", item.Title, "changes", len(changes))
+
+	// A listing rejected at first sight may now match, e.g. after a price drop
+	if ok, _ := filter.Match(item); ok && s.shouldNotifyChange(change) {
+		if err := s.announceChange(ctx, change); err != nil {
+			logger.Error("failed to notify change", "id", item.ID, "error", err)
+			report.fail(item.Key(), "notify", err)
+			// Same strategy as new items: keep the old snapshot to retry on the next run
+			return false
+		}
+	}
+
+	report.Changed++
+	return true
+}
+
+// announceChange enqueues an item update when an outbox is configured, or notifies it directly.
+func (s *WatcherService) announceChange(ctx context.Context, change ItemChange) error {
+	if s.outbox != nil {
+		return s.enqueue(ctx, Delivery{Kind: DeliveryChanged, Item: change.Item, Previous: change.Previous, Changes: change.Changes})
+	}
+
+	var errs []error
+	for _, t := range s.targets(change.Item) {
+		if err := NotifyChange(ctx, t.channel.Notifier, change); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", t.key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// announceRemoval enqueues a removal when an outbox is configured, or notifies it directly.
+func (s *WatcherService) announceRemoval(ctx context.Context, item Item) error {
+	if s.outbox != nil {
+		return s.enqueue(ctx, Delivery{Kind: DeliveryRemoved, Item: item})
+	}
+
+	// Removals reach the subscriptions the last known version of the listing matched
+	var errs []error
+	for _, t := range s.targets(item) {
+		if err := NotifyRemoval(ctx, t.channel.Notifier, item); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", t.key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// announceSummary enqueues a bootstrap summary when an outbox is configured, or notifies it directly.
+func (s *WatcherService) announceSummary(ctx context.Context, summary BootstrapSummary) error {
+	if s.outbox != nil {
+		return s.enqueue(ctx, Delivery{Kind: DeliverySummary, Item: Item{Provider: summary.Provider}, Summary: summary})
+	}
+
+	var errs []error
+	for _, t := range s.summaryTargets(summary.Provider) {
+		if err := NotifySummary(ctx, t.channel.Notifier, summary); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", t.key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// enqueue stores one pending delivery per channel of the subscriptions matching each event.
+func (s *WatcherService) enqueue(ctx context.Context, events ...Delivery) error {
+	now := time.Now()
+
+	var deliveries []Delivery
+	for _, event := range events {
+		targets := s.targets(event.Item)
+		if event.Kind == DeliverySummary {
+			targets = s.summaryTargets(event.Summary.Provider)
+		}
+		for _, t := range targets {
+			d := event
+			d.Channel = t.key
+			d.ID = deliveryID(d)
+			d.Status = DeliveryPending
+			d.NextAttemptAt = t.channel.Quiet.DeliverAt(d.Item, now)
+			deliveries = append(deliveries, d)
+		}
+	}
+
+	return s.outbox.Enqueue(ctx, deliveries)
+}
+
+// deliver attempts the due deliveries of the outbox, channel by channel.
+// New items of a channel are sent as one batch when its notifier supports it,
+// so the items held during quiet hours make a single digest once the window ends.
+func (s *WatcherService) deliver(ctx context.Context) {
+	if s.outbox == nil {
+		return
+	}
+
+	s.deliverMu.Lock()
+	defer s.deliverMu.Unlock()
+
+	now := time.Now()
+	due, err := s.outbox.Due(ctx, now)
+	if err != nil {
+		s.logger.Error("failed to read outbox", "error", err)
+		return
+	}
+
+	byChannel := make(map[string][]Delivery)
+	for _, d := range due {
+		byChannel[d.Channel] = append(byChannel[d.Channel], d)
+	}
+
+	for _, t := range s.allTargets() {
+		ch := t.channel
+		var fresh []Delivery
+		for _, d := range byChannel[t.key] {
+			// Retries falling in quiet hours wait for the end of the window, without spending an attempt
+			if at := ch.Quiet.DeliverAt(d.Item, now); at.After(now) {
+				s.postpone(ctx, d, at)
+				continue
+			}
+
+			switch d.Kind {
+			case DeliveryNew:
+				fresh = append(fresh, d)
+			case DeliveryChanged:
+				change := ItemChange{Item: d.Item, Previous: d.Previous, Changes: d.Changes}
+				s.complete(ctx, d, NotifyChange(ctx, ch.Notifier, change), now)
+			case DeliveryRemoved:
+				s.complete(ctx, d, NotifyRemoval(ctx, ch.Notifier, d.Item), now)
+			case DeliverySummary:
+				s.complete(ctx, d, NotifySummary(ctx, ch.Notifier, d.Summary), now)
+			}
+		}
+
+		if len(fresh) == 0 {
+			continue
+		}
+
+		if bn, ok := ch.Notifier.(BatchNotifier); ok {
+			items := make([]Item, 0, len(fresh))
+			for _, d := range fresh {
+				items = append(items, d.Item)
+			}
+			err := bn.SendBatch(ctx, items)
+			for _, d := range fresh {
+				s.complete(ctx, d, err, now)
+			}
+			continue
+		}
+
+		for _, d := range fresh {
+			s.complete(ctx, d, ch.Notifier.Send(ctx, d.Item), now)
+		}
+	}
+
+	s.prune(ctx, now)
+}
+
+// prune drops the deliveries completed before the retention period, when the outbox supports it.
+func (s *WatcherService) prune(ctx context.Context, now time.Time) {
+	pruner, ok := s.outbox.(OutboxPruner)
+	if !ok || s.retention <= 0 {
+		return
+	}
+	pruned, err := pruner.Prune(ctx, now.Add(-s.retention))
+	if err != nil {
+		s.logger.Error("failed to prune outbox", "error", err)
+		return
+	}
+	if pruned > 0 {
+		s.logger.Debug("outbox pruned", "deliveries", pruned, "retention", s.retention)
+	}
+}
+
+// allTargets returns every channel of every subscription.
+func (s *WatcherService) allTargets() []target {
+	var targets []target
+	for _, sub := range s.subscriptions {
+		for _, ch := range sub.Channels {
+			targets = append(targets, target{key: channelKey(sub.Name, ch.Name), channel: ch})
+		}
+	}
+	return targets
+}
+
+// postpone reschedules a delivery held by quiet hours.
+func (s *WatcherService) postpone(ctx context.Context, d Delivery, at time.Time) {
+	d.NextAttemptAt = at
+	if err := s.outbox.Update(ctx, d); err != nil {
+		s.logger.Error("failed to postpone delivery", "channel", d.Channel, "id", d.Item.ID, "error", err)
+		return
+	}
+	s.logger.Debug("delivery held by quiet hours", "channel", d.Channel, "id", d.Item.ID, "next_attempt_at", at)
+}
+
+// complete records the outcome of a delivery attempt, scheduling a retry or dead-lettering it on failure.
+func (s *WatcherService) complete(ctx context.Context, d Delivery, sendErr error, now time.Time) {
+	logger := s.logger.With("channel", d.Channel, "kind", d.Kind, "id", d.Item.ID, "provider", d.Item.Provider)
+
+	d.Attempts++
+	switch {
+	case sendErr == nil:
+		d.Status = DeliverySent
+		d.LastError = ""
+		d.CompletedAt = now
+	case d.Attempts >= s.retry.MaxAttempts:
+		d.Status = DeliveryFailed
+		d.LastError = sendErr.Error()
+		d.CompletedAt = now
+		logger.Error("delivery abandoned after max attempts", "attempts", d.Attempts, "error", sendErr)
+	default:
+		d.NextAttemptAt = now.Add(s.retry.Backoff(d.Attempts))
+		d.LastError = sendErr.Error()
+		logger.Warn("delivery failed, retry scheduled", "attempts", d.Attempts, "next_attempt_at", d.NextAttemptAt, "error", sendErr)
+	}
+
+	// If a sent delivery cannot be recorded it is sent again later (At-Least-Once delivery)
+	if err := s.outbox.Update(ctx, d); err != nil {
+		logger.Error("failed to update delivery", "error", err)
+	}
+}
+
+func (s *WatcherService) shouldNotifyChange(change ItemChange) bool {
+	switch s.changePolicy {
+	case NotifyPriceDrops:
+		return change.PriceDropped()
+	case IgnoreChanges:
+		return false
+	}
+	return true
 }
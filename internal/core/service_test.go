@@ -4,11 +4,17 @@ import (
 	"context"
 	"errors"
 	"io"
+	"iter"
 	"log/slog"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 type mockProvider struct {
+	name  string
 	items []Item
 	err   error
 }
@@ -16,22 +22,68 @@ type mockProvider struct {
 func (m *mockProvider) FetchItems(ctx context.Context) ([]Item, error) {
 	return m.items, m.err
 }
-func (m *mockProvider) Name() string { return "MockProvider" }
+
+func (m *mockProvider) Name() string {
+	if m.name == "" {
+		return "MockProvider"
+	}
+	return m.name
+}
+
+// streamYield is a value yielded by a mockStreamProvider.
+type streamYield struct {
+	item Item
+	err  error
+}
+
+type mockStreamProvider struct {
+	yields []streamYield
+	// onYield, when set, runs before each value is yielded
+	onYield func(i int)
+}
+
+func (m *mockStreamProvider) Name() string {
+	return "MockStreamProvider"
+}
+
+func (m *mockStreamProvider) FetchItems(ctx context.Context) ([]Item, error) {
+	return Collect(m.StreamItems(ctx))
+}
+
+func (m *mockStreamProvider) StreamItems(ctx context.Context) iter.Seq2[Item, error] {
+	return func(yield func(Item, error) bool) {
+		for i, y := range m.yields {
+			if m.onYield != nil {
+				m.onYield(i)
+			}
+			if !yield(y.item, y.err) {
+				return
+			}
+		}
+	}
+}
+
+// Mocks below are shared by concurrent provider runs, hence the mutexes.
 
 type mockRepository struct {
-	exists map[string]bool // Simulate database state
-	saved  []Item          // Store saved items to verify assertions
-	err    error           // Simulate DB error
+	mu     sync.Mutex
+	exists map[ItemKey]bool // Simulate database state
+	saved  []Item           // Store saved items to verify assertions
+	err    error            // Simulate DB error
 }
 
-func (m *mockRepository) Exists(ctx context.Context, id string) (bool, error) {
+func (m *mockRepository) Exists(ctx context.Context, key ItemKey) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.err != nil {
 		return false, m.err
 	}
-	return m.exists[id], nil
+	return m.exists[key], nil
 }
 
 func (m *mockRepository) Save(ctx context.Context, item Item) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.err != nil {
 		return m.err
 	}
@@ -40,11 +92,14 @@ func (m *mockRepository) Save(ctx context.Context, item Item) error {
 }
 
 type mockNotifier struct {
+	mu   sync.Mutex
 	sent []Item // Store sent notifications
 	err  error  // Simulate SMTP error
 }
 
 func (m *mockNotifier) Send(ctx context.Context, item Item) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if m.err != nil {
 		return m.err
 	}
@@ -63,7 +118,7 @@ func TestWatcherService_Run(t *testing.T) {
 		name          string
 		providerItems []Item
 		providerErr   error
-		repoExisting  map[string]bool
+		repoExisting  map[ItemKey]bool
 		repoErr       error
 		notifierErr   error
 		expectError   bool // Do we expect Run() to return an error?
@@ -73,7 +128,7 @@ func TestWatcherService_Run(t *testing.T) {
 		{
 			name:          "Nominal Case: New item found",
 			providerItems: []Item{validItem},
-			repoExisting:  map[string]bool{}, // Empty DB
+			repoExisting:  map[ItemKey]bool{}, // Empty DB
 			expectError:   false,
 			expectedSaved: 1,
 			expectedSent:  1,
@@ -81,7 +136,7 @@ func TestWatcherService_Run(t *testing.T) {
 		{
 			name:          "Idempotency: Item already exists",
 			providerItems: []Item{validItem},
-			repoExisting:  map[string]bool{"1": true}, // Item "1" already seen
+			repoExisting:  map[ItemKey]bool{{Provider: "MockProvider", ID: "1"}: true}, // Item "1" already seen
 			expectError:   false,
 			expectedSaved: 0, // Should NOT save again
 			expectedSent:  0, // Should NOT notify again
@@ -129,10 +184,10 @@ func TestWatcherService_Run(t *testing.T) {
 			mockNotif := &mockNotifier{err: tt.notifierErr}
 
 			// Instantiate Service
-			svc := NewWatcherService(mockProv, mockRepo, mockNotif, logger)
+			svc := NewWatcherService([]Provider{mockProv}, mockRepo, mockNotif, logger)
 
 			// Execute
-			err := svc.Run(context.Background())
+			_, err := svc.Run(context.Background())
 
 			// Assertions
 			if (err != nil) != tt.expectError {
@@ -149,3 +204,1192 @@ func TestWatcherService_Run(t *testing.T) {
 		})
 	}
 }
+
+func TestWatcherService_Run_MultipleProviders(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	healthy := &mockProvider{name: "healthy", items: []Item{
+		{ID: "1", Title: "First", Url: "https://test.com/1"},
+		{ID: "2", Title: "Second", Url: "https://test.com/2"},
+	}}
+	broken := &mockProvider{name: "broken", err: errors.New("network timeout")}
+	other := &mockProvider{name: "other", items: []Item{
+		{ID: "3", Title: "Third", Url: "https://test.com/3"},
+	}}
+
+	mockRepo := &mockRepository{exists: map[ItemKey]bool{}}
+	mockNotif := &mockNotifier{}
+
+	svc := NewWatcherService([]Provider{healthy, broken, other}, mockRepo, mockNotif, logger, WithConcurrency(1))
+
+	_, err := svc.Run(context.Background())
+
+	// The broken provider must be reported...
+	if err == nil {
+		t.Fatal("Run() should report the failing provider")
+	}
+	if !strings.Contains(err.Error(), "broken") {
+		t.Errorf("Run() error should name the failing provider, got: %v", err)
+	}
+
+	// ...without preventing the others from being processed.
+	if len(mockRepo.saved) != 3 {
+		t.Errorf("Repo.Save() called %d times, want 3", len(mockRepo.saved))
+	}
+	if len(mockNotif.sent) != 3 {
+		t.Errorf("Notifier.Send() called %d times, want 3", len(mockNotif.sent))
+	}
+}
+
+func TestWatcherService_RunProvider(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	first := &mockProvider{name: "first", items: []Item{{ID: "1", Title: "First", Url: "https://test.com/1"}}}
+	second := &mockProvider{name: "second", items: []Item{{ID: "2", Title: "Second", Url: "https://test.com/2"}}}
+
+	mockRepo := &mockRepository{exists: map[ItemKey]bool{}}
+	svc := NewWatcherService([]Provider{first, second}, mockRepo, &mockNotifier{}, logger)
+
+	report, err := svc.RunProvider(context.Background(), "second")
+	if err != nil {
+		t.Fatalf("RunProvider() returned an unexpected error: %v", err)
+	}
+	if len(report.Providers) != 1 || report.Providers[0].Provider != "second" {
+		t.Errorf("RunProvider() should only report the requested provider, got %+v", report.Providers)
+	}
+	if len(mockRepo.saved) != 1 || mockRepo.saved[0].ID != "2" {
+		t.Errorf("RunProvider() should only process the requested provider, saved %v", mockRepo.saved)
+	}
+
+	if _, err := svc.RunProvider(context.Background(), "missing"); err == nil {
+		t.Error("RunProvider() should reject an unknown provider")
+	}
+}
+
+func TestWatcherService_Run_ScopesIdentityByProvider(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// Both sources publish an item with the same raw ID
+	dogs := &mockProvider{name: "dogs", items: []Item{{ID: "1234", Title: "Rex", Url: "https://dogs.test/1234"}}}
+	flats := &mockProvider{name: "flats", items: []Item{{ID: "1234", Title: "T2", Url: "https://flats.test/1234"}}}
+
+	// Only the dog was already seen
+	mockRepo := &mockRepository{exists: map[ItemKey]bool{{Provider: "dogs", ID: "1234"}: true}}
+	mockNotif := &mockNotifier{}
+
+	svc := NewWatcherService([]Provider{dogs, flats}, mockRepo, mockNotif, logger)
+
+	if _, err := svc.Run(context.Background()); err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+
+	if len(mockNotif.sent) != 1 {
+		t.Fatalf("Notifier.Send() called %d times, want 1", len(mockNotif.sent))
+	}
+	if got := mockNotif.sent[0].Key(); got != (ItemKey{Provider: "flats", ID: "1234"}) {
+		t.Errorf("Notified item key = %v, want flats/1234", got)
+	}
+}
+
+// mockSnapshotRepository stores full items to exercise change detection.
+type mockSnapshotRepository struct {
+	mockRepository
+	snapshots map[ItemKey]Item
+}
+
+func (m *mockSnapshotRepository) Get(ctx context.Context, key ItemKey) (Item, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, known := m.snapshots[key]
+	return item, known, nil
+}
+
+type mockChangeNotifier struct {
+	mockNotifier
+	changes []ItemChange
+}
+
+func (m *mockChangeNotifier) SendChange(ctx context.Context, change ItemChange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.changes = append(m.changes, change)
+	return nil
+}
+
+func TestWatcherService_Run_ChangeDetection(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	stored := Item{ID: "1", Provider: "MockProvider", Title: "T2", Price: 900, Currency: "EUR", Url: "https://test.com/1"}
+	cheaper := stored
+	cheaper.Price = 850
+	renamed := stored
+	renamed.Title = "T2 refait à neuf"
+
+	tests := []struct {
+		name            string
+		fetched         Item
+		stored          Item
+		policy          ChangePolicy
+		notifierErr     error
+		expectedChanges int // How many change notifications should be sent?
+		expectedSaved   int // How many snapshots should be written?
+	}{
+		{
+			name:            "Unchanged item is ignored",
+			fetched:         stored,
+			stored:          stored,
+			expectedChanges: 0,
+			expectedSaved:   0,
+		},
+		{
+			name:            "Price drop is notified and snapshot refreshed",
+			fetched:         cheaper,
+			stored:          stored,
+			expectedChanges: 1,
+			expectedSaved:   1,
+		},
+		{
+			name:            "Policy price-drop ignores other updates but refreshes snapshot",
+			fetched:         renamed,
+			stored:          stored,
+			policy:          NotifyPriceDrops,
+			expectedChanges: 0,
+			expectedSaved:   1,
+		},
+		{
+			name:            "Legacy row without snapshot records a baseline silently",
+			fetched:         cheaper,
+			stored:          Item{},
+			expectedChanges: 0,
+			expectedSaved:   1,
+		},
+		{
+			name:            "At-Least-Once Delivery: If change notification fails, keep old snapshot",
+			fetched:         cheaper,
+			stored:          stored,
+			notifierErr:     errors.New("smtp down"),
+			expectedChanges: 0,
+			expectedSaved:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockProv := &mockProvider{items: []Item{tt.fetched}}
+			mockRepo := &mockSnapshotRepository{snapshots: map[ItemKey]Item{stored.Key(): tt.stored}}
+			mockNotif := &mockChangeNotifier{mockNotifier: mockNotifier{err: tt.notifierErr}}
+
+			svc := NewWatcherService([]Provider{mockProv}, mockRepo, mockNotif, logger, WithChangePolicy(tt.policy))
+
+			if _, err := svc.Run(context.Background()); err != nil {
+				t.Fatalf("Run() returned an unexpected error: %v", err)
+			}
+
+			if len(mockNotif.changes) != tt.expectedChanges {
+				t.Errorf("Notifier.SendChange() called %d times, want %d", len(mockNotif.changes), tt.expectedChanges)
+			}
+			if len(mockNotif.sent) != 0 {
+				t.Errorf("Notifier.Send() called %d times, want 0 for a known item", len(mockNotif.sent))
+			}
+			if len(mockRepo.saved) != tt.expectedSaved {
+				t.Errorf("Repo.Save() called %d times, want %d", len(mockRepo.saved), tt.expectedSaved)
+			}
+		})
+	}
+}
+
+// mockPresenceRepository records reconciliations to exercise removal detection.
+type mockPresenceRepository struct {
+	mockRepository
+	reconciled [][]ItemKey
+	removed    []Item // Returned by every Reconcile call
+}
+
+func (m *mockPresenceRepository) Reconcile(ctx context.Context, provider string, seen []ItemKey, threshold int, at time.Time) ([]Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reconciled = append(m.reconciled, seen)
+	return m.removed, nil
+}
+
+type mockRemovalNotifier struct {
+	mockNotifier
+	removals []Item
+}
+
+func (m *mockRemovalNotifier) SendRemoval(ctx context.Context, item Item) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removals = append(m.removals, item)
+	return nil
+}
+
+func TestWatcherService_Run_RemovalDetection(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	validItem := Item{ID: "1", Title: "Valid Item", Url: "https://test.com"}
+	adopted := Item{ID: "2", Provider: "MockProvider", Title: "Adopted", Url: "https://test.com/2"}
+
+	tests := []struct {
+		name               string
+		providerItems      []Item
+		providerErr        error
+		threshold          int
+		notify             bool
+		expectedReconciles int // How many complete scans should be reconciled?
+		expectedRemovals   int // How many "listing gone" notifications should be sent?
+	}{
+		{
+			name:               "Nominal Case: Complete scan is reconciled and removal notified",
+			providerItems:      []Item{validItem},
+			threshold:          3,
+			notify:             true,
+			expectedReconciles: 1,
+			expectedRemovals:   1,
+		},
+		{
+			name:               "Removals are not notified unless enabled",
+			providerItems:      []Item{validItem},
+			threshold:          3,
+			expectedReconciles: 1,
+			expectedRemovals:   0,
+		},
+		{
+			name:               "Failed fetch does not count as missing",
+			providerErr:        errors.New("network timeout"),
+			threshold:          3,
+			notify:             true,
+			expectedReconciles: 0,
+			expectedRemovals:   0,
+		},
+		{
+			name:               "Empty scan does not count as missing",
+			providerItems:      []Item{},
+			threshold:          3,
+			notify:             true,
+			expectedReconciles: 0,
+			expectedRemovals:   0,
+		},
+		{
+			name:               "Disabled detection",
+			providerItems:      []Item{validItem},
+			threshold:          0,
+			notify:             true,
+			expectedReconciles: 0,
+			expectedRemovals:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockProv := &mockProvider{items: tt.providerItems, err: tt.providerErr}
+			mockRepo := &mockPresenceRepository{removed: []Item{adopted}}
+			mockNotif := &mockRemovalNotifier{}
+
+			svc := NewWatcherService([]Provider{mockProv}, mockRepo, mockNotif, logger,
+				WithRemovalDetection(tt.threshold, tt.notify),
+			)
+
+			_, _ = svc.Run(context.Background())
+
+			if len(mockRepo.reconciled) != tt.expectedReconciles {
+				t.Errorf("Repo.Reconcile() called %d times, want %d", len(mockRepo.reconciled), tt.expectedReconciles)
+			}
+			if len(mockNotif.removals) != tt.expectedRemovals {
+				t.Errorf("Notifier.SendRemoval() called %d times, want %d", len(mockNotif.removals), tt.expectedRemovals)
+			}
+		})
+	}
+}
+
+func TestWatcherService_Run_Filters(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	cheap := Item{ID: "1", Title: "Studio", Price: 450, Url: "https://test.com/1"}
+	expensive := Item{ID: "2", Title: "Loft", Price: 1500, Url: "https://test.com/2"}
+
+	mockProv := &mockProvider{items: []Item{cheap, expensive}}
+	mockRepo := &mockRepository{exists: map[ItemKey]bool{}}
+	mockNotif := &mockNotifier{}
+
+	svc := NewWatcherService([]Provider{mockProv}, mockRepo, mockNotif, logger,
+		WithFilters(map[string]Filter{"MockProvider": {MaxPrice: 1000}}),
+	)
+
+	if _, err := svc.Run(context.Background()); err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+
+	// Only the matching item is notified...
+	if len(mockNotif.sent) != 1 || mockNotif.sent[0].ID != "1" {
+		t.Errorf("Notifier.Send() got %v, want only item 1", mockNotif.sent)
+	}
+
+	// ...but both are marked as seen so the filtered one is not re-evaluated.
+	if len(mockRepo.saved) != 2 {
+		t.Errorf("Repo.Save() called %d times, want 2", len(mockRepo.saved))
+	}
+}
+
+type mockBatchNotifier struct {
+	mockNotifier
+	batches [][]Item
+}
+
+func (m *mockBatchNotifier) SendBatch(ctx context.Context, items []Item) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.batches = append(m.batches, items)
+	return nil
+}
+
+func TestWatcherService_Run_Digest(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	items := []Item{
+		{ID: "1", Title: "First", Url: "https://test.com/1"},
+		{ID: "2", Title: "Second", Url: "https://test.com/2"},
+		{ID: "3", Title: "Third", Url: "https://test.com/3"},
+	}
+
+	tests := []struct {
+		name            string
+		notifierErr     error
+		expectedBatches int
+		expectedSaved   int
+	}{
+		{
+			name:            "Nominal Case: One batch per run",
+			expectedBatches: 1,
+			expectedSaved:   3,
+		},
+		{
+			name:            "At-Least-Once Delivery: If the batch fails, save nothing",
+			notifierErr:     errors.New("smtp down"),
+			expectedBatches: 0,
+			expectedSaved:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockProv := &mockProvider{items: items}
+			mockRepo := &mockRepository{exists: map[ItemKey]bool{}}
+			mockNotif := &mockBatchNotifier{mockNotifier: mockNotifier{err: tt.notifierErr}}
+
+			svc := NewWatcherService([]Provider{mockProv}, mockRepo, mockNotif, logger)
+
+			if _, err := svc.Run(context.Background()); err != nil {
+				t.Fatalf("Run() returned an unexpected error: %v", err)
+			}
+
+			if len(mockNotif.batches) != tt.expectedBatches {
+				t.Errorf("Notifier.SendBatch() called %d times, want %d", len(mockNotif.batches), tt.expectedBatches)
+			}
+			if len(mockNotif.sent) != 0 {
+				t.Errorf("Notifier.Send() called %d times, want 0 when batching is available", len(mockNotif.sent))
+			}
+			if len(mockRepo.saved) != tt.expectedSaved {
+				t.Errorf("Repo.Save() called %d times, want %d", len(mockRepo.saved), tt.expectedSaved)
+			}
+		})
+	}
+}
+
+// mockOutbox keeps deliveries in memory, deduplicating by ID like real outboxes.
+type mockOutbox struct {
+	mu         sync.Mutex
+	deliveries map[string]Delivery
+	enqueueErr error
+}
+
+func (m *mockOutbox) Enqueue(ctx context.Context, deliveries []Delivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.enqueueErr != nil {
+		return m.enqueueErr
+	}
+	if m.deliveries == nil {
+		m.deliveries = make(map[string]Delivery)
+	}
+	for _, d := range deliveries {
+		if _, exists := m.deliveries[d.ID]; !exists {
+			m.deliveries[d.ID] = d
+		}
+	}
+	return nil
+}
+
+func (m *mockOutbox) Due(ctx context.Context, now time.Time) ([]Delivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var due []Delivery
+	for _, d := range m.deliveries {
+		if d.Status == DeliveryPending && !d.NextAttemptAt.After(now) {
+			due = append(due, d)
+		}
+	}
+	return due, nil
+}
+
+func (m *mockOutbox) Update(ctx context.Context, d Delivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deliveries[d.ID] = d
+	return nil
+}
+
+func (m *mockOutbox) Prune(ctx context.Context, cutoff time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pruned := 0
+	for id, d := range m.deliveries {
+		if d.Status != DeliveryPending && d.CompletedAt.Before(cutoff) {
+			delete(m.deliveries, id)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+func (m *mockOutbox) byStatus(status DeliveryStatus) []Delivery {
+	var found []Delivery
+	for _, d := range m.deliveries {
+		if d.Status == status {
+			found = append(found, d)
+		}
+	}
+	return found
+}
+
+func TestWatcherService_Run_Outbox(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	validItem := Item{ID: "1", Title: "Valid Item", Url: "https://test.com"}
+
+	t.Run("Nominal Case: Delivered through every channel, then marked sent", func(t *testing.T) {
+		outbox := &mockOutbox{}
+		email := &mockNotifier{}
+		logs := &mockNotifier{}
+		mockRepo := &mockRepository{exists: map[ItemKey]bool{}}
+
+		svc := NewWatcherService([]Provider{&mockProvider{items: []Item{validItem}}}, mockRepo, &mockNotifier{}, logger,
+			WithOutbox(outbox, RetryPolicy{}, Channel{Name: "email", Notifier: email}, Channel{Name: "log", Notifier: logs}),
+		)
+
+		if _, err := svc.Run(context.Background()); err != nil {
+			t.Fatalf("Run() returned an unexpected error: %v", err)
+		}
+
+		if len(email.sent) != 1 || len(logs.sent) != 1 {
+			t.Errorf("Expected one notification per channel, got email=%d log=%d", len(email.sent), len(logs.sent))
+		}
+		if len(outbox.byStatus(DeliverySent)) != 2 {
+			t.Errorf("Expected 2 sent deliveries, got %v", outbox.deliveries)
+		}
+		if len(mockRepo.saved) != 1 {
+			t.Errorf("Repo.Save() called %d times, want 1", len(mockRepo.saved))
+		}
+	})
+
+	t.Run("Failing channel is retried with backoff without notifying the others twice", func(t *testing.T) {
+		outbox := &mockOutbox{}
+		email := &mockNotifier{err: errors.New("smtp down")}
+		logs := &mockNotifier{}
+		mockRepo := &mockRepository{exists: map[ItemKey]bool{}}
+
+		svc := NewWatcherService([]Provider{&mockProvider{items: []Item{validItem}}}, mockRepo, &mockNotifier{}, logger,
+			WithOutbox(outbox, RetryPolicy{MaxAttempts: 2, BaseDelay: time.Hour}, Channel{Name: "email", Notifier: email}, Channel{Name: "log", Notifier: logs}),
+		)
+
+		_, _ = svc.Run(context.Background())
+
+		// The item is seen: its notification is safe in the outbox
+		if len(mockRepo.saved) != 1 {
+			t.Errorf("Repo.Save() called %d times, want 1", len(mockRepo.saved))
+		}
+		pending := outbox.byStatus(DeliveryPending)
+		if len(pending) != 1 || pending[0].Channel != "email" || pending[0].Attempts != 1 {
+			t.Fatalf("Expected the email delivery to be pending after one attempt, got %v", pending)
+		}
+		if !pending[0].NextAttemptAt.After(time.Now().Add(59 * time.Minute)) {
+			t.Errorf("Expected retry to be delayed by the backoff, got %v", pending[0].NextAttemptAt)
+		}
+
+		// Second attempt, once due: reaches the max attempts and is dead-lettered
+		d := pending[0]
+		d.NextAttemptAt = time.Now()
+		_ = outbox.Update(context.Background(), d)
+		mockRepo.exists[ItemKey{Provider: "MockProvider", ID: "1"}] = true
+
+		_, _ = svc.Run(context.Background())
+
+		if failed := outbox.byStatus(DeliveryFailed); len(failed) != 1 || failed[0].Attempts != 2 {
+			t.Errorf("Expected the email delivery to be dead-lettered, got %v", outbox.deliveries)
+		}
+		if len(logs.sent) != 1 {
+			t.Errorf("Log channel notified %d times, want 1", len(logs.sent))
+		}
+	})
+
+	t.Run("Save failure does not duplicate notifications", func(t *testing.T) {
+		outbox := &mockOutbox{}
+		email := &mockNotifier{}
+		mockRepo := &mockRepository{exists: map[ItemKey]bool{}}
+		failingRepo := &saveFailingRepository{mockRepository: mockRepo}
+
+		svc := NewWatcherService([]Provider{&mockProvider{items: []Item{validItem}}}, failingRepo, &mockNotifier{}, logger,
+			WithOutbox(outbox, RetryPolicy{}, Channel{Name: "email", Notifier: email}),
+		)
+
+		// The item is never saved, so each run sees it as new again
+		_, _ = svc.Run(context.Background())
+		_, _ = svc.Run(context.Background())
+
+		if len(email.sent) != 1 {
+			t.Errorf("Email channel notified %d times, want 1", len(email.sent))
+		}
+	})
+
+	t.Run("Enqueue failure keeps the item unseen", func(t *testing.T) {
+		outbox := &mockOutbox{enqueueErr: errors.New("db connection lost")}
+		mockRepo := &mockRepository{exists: map[ItemKey]bool{}}
+
+		svc := NewWatcherService([]Provider{&mockProvider{items: []Item{validItem}}}, mockRepo, &mockNotifier{}, logger,
+			WithOutbox(outbox, RetryPolicy{}),
+		)
+
+		_, _ = svc.Run(context.Background())
+
+		if len(mockRepo.saved) != 0 {
+			t.Errorf("Repo.Save() called %d times, want 0", len(mockRepo.saved))
+		}
+	})
+
+	t.Run("Quiet hours hold deliveries until the window ends, except urgent items", func(t *testing.T) {
+		outbox := &mockOutbox{}
+		email := &mockNotifier{}
+		mockRepo := &mockRepository{exists: map[ItemKey]bool{}}
+		urgent := Item{ID: "2", Title: "Rex", Url: "https://test.com/rex", Animal: &AnimalAttributes{Urgent: true}}
+
+		// A two-hour window around the current time
+		hour := time.Now().UTC().Hour()
+		quiet := QuietHours{Start: time.Duration(hour) * time.Hour, End: time.Duration((hour+2)%24) * time.Hour, AllowUrgent: true}
+
+		svc := NewWatcherService([]Provider{&mockProvider{items: []Item{validItem, urgent}}}, mockRepo, &mockNotifier{}, logger,
+			WithOutbox(outbox, RetryPolicy{}, Channel{Name: "email", Notifier: email, Quiet: quiet}),
+		)
+
+		if _, err := svc.Run(context.Background()); err != nil {
+			t.Fatalf("Run() returned an unexpected error: %v", err)
+		}
+
+		if len(email.sent) != 1 || email.sent[0].ID != "2" {
+			t.Errorf("Expected only the urgent item to be notified, got %v", email.sent)
+		}
+		pending := outbox.byStatus(DeliveryPending)
+		if len(pending) != 1 || pending[0].Item.ID != "1" || pending[0].Attempts != 0 {
+			t.Fatalf("Expected the regular item to be held without attempt, got %v", pending)
+		}
+		if !pending[0].NextAttemptAt.After(time.Now()) || quiet.Contains(pending[0].NextAttemptAt) {
+			t.Errorf("Expected the delivery to be scheduled at the end of the window, got %v", pending[0].NextAttemptAt)
+		}
+		if len(mockRepo.saved) != 2 {
+			t.Errorf("Repo.Save() called %d times, want 2", len(mockRepo.saved))
+		}
+	})
+
+	t.Run("Completed deliveries are pruned after the retention period", func(t *testing.T) {
+		old := time.Now().Add(-48 * time.Hour)
+		outbox := &mockOutbox{deliveries: map[string]Delivery{
+			"old-sent":    {ID: "old-sent", Status: DeliverySent, CompletedAt: old},
+			"old-failed":  {ID: "old-failed", Status: DeliveryFailed, CompletedAt: old},
+			"recent-sent": {ID: "recent-sent", Status: DeliverySent, CompletedAt: time.Now().Add(-time.Hour)},
+			"old-pending": {ID: "old-pending", Status: DeliveryPending, NextAttemptAt: time.Now().Add(time.Hour)},
+		}}
+		email := &mockNotifier{}
+		mockRepo := &mockRepository{exists: map[ItemKey]bool{}}
+
+		svc := NewWatcherService([]Provider{&mockProvider{items: []Item{validItem}}}, mockRepo, &mockNotifier{}, logger,
+			WithOutbox(outbox, RetryPolicy{}, Channel{Name: "email", Notifier: email}),
+			WithOutboxRetention(24*time.Hour),
+		)
+
+		if _, err := svc.Run(context.Background()); err != nil {
+			t.Fatalf("Run() returned an unexpected error: %v", err)
+		}
+
+		var kept []string
+		for id := range outbox.deliveries {
+			kept = append(kept, id)
+		}
+		slices.Sort(kept)
+		// The delivery of the run is sent and completed now, so it is kept as well
+		if len(kept) != 3 || kept[0] != "email|new|MockProvider/1" || kept[1] != "old-pending" || kept[2] != "recent-sent" {
+			t.Errorf("Deliveries kept = %v, want the pending and recently completed ones", kept)
+		}
+		if sent := outbox.deliveries["email|new|MockProvider/1"]; sent.Status != DeliverySent || sent.CompletedAt.IsZero() {
+			t.Errorf("Delivery of the run = %+v, want it sent with its completion time", sent)
+		}
+	})
+}
+
+// saveFailingRepository answers lookups but never manages to save.
+type saveFailingRepository struct {
+	*mockRepository
+}
+
+func (m *saveFailingRepository) Save(ctx context.Context, item Item) error {
+	return errors.New("disk full")
+}
+
+func TestWatcherService_Run_Report(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	mixed := &mockProvider{name: "mixed", items: []Item{
+		{ID: "1", Title: "New", Price: 500, Url: "https://test.com/1"},
+		{ID: "2", Title: "Known", Price: 500, Url: "https://test.com/2"},
+		{ID: "3", Title: "Too expensive", Price: 5000, Url: "https://test.com/3"},
+		{ID: "", Title: "Invalid"},
+	}}
+	// Partial results: some pages failed
+	partial := &mockProvider{name: "partial", items: []Item{
+		{ID: "4", Title: "Partial", Url: "https://test.com/4"},
+	}, err: &PartialFetchError{Pages: []PageError{{Page: 2, Err: errors.New("status 503")}}, Total: 2}}
+
+	mockRepo := &mockPresenceRepository{mockRepository: mockRepository{exists: map[ItemKey]bool{{Provider: "mixed", ID: "2"}: true}}}
+
+	svc := NewWatcherService([]Provider{mixed, partial}, mockRepo, &mockNotifier{}, logger,
+		WithFilters(map[string]Filter{"mixed": {MaxPrice: 1000}}),
+		WithRemovalDetection(3, false),
+	)
+
+	report, err := svc.Run(context.Background())
+
+	if err == nil {
+		t.Error("Run() should report the partial provider error")
+	}
+	if len(report.Providers) != 2 || report.Duration <= 0 {
+		t.Fatalf("Run() report should describe each provider and the run duration, got %+v", report)
+	}
+
+	got := report.Providers[0]
+	want := ProviderReport{Provider: "mixed", Fetched: 4, Invalid: 1, AlreadySeen: 1, Filtered: 1, Notified: 1, Saved: 2}
+	if got.Provider != want.Provider || got.Fetched != want.Fetched || got.Invalid != want.Invalid ||
+		got.AlreadySeen != want.AlreadySeen || got.Filtered != want.Filtered || got.Notified != want.Notified ||
+		got.Saved != want.Saved || got.Failed != 0 || got.Degraded {
+		t.Errorf("Provider report = %+v, want counts of %+v", got, want)
+	}
+
+	degraded := report.Providers[1]
+	if !degraded.Degraded || degraded.FailedPages != 1 || degraded.Notified != 1 || !report.Degraded() {
+		t.Errorf("Partial provider should be processed and flagged degraded, got %+v", degraded)
+	}
+
+	// Only the complete scan is reconciled
+	if len(mockRepo.reconciled) != 1 {
+		t.Errorf("Repo.Reconcile() called %d times, want 1", len(mockRepo.reconciled))
+	}
+}
+
+// mockBatchRepository counts round-trips to verify the absence of N+1 queries.
+type mockBatchRepository struct {
+	mockSnapshotRepository
+	getManyCalls  int
+	saveManyCalls int
+}
+
+func (m *mockBatchRepository) ExistsMany(ctx context.Context, keys []ItemKey) (map[ItemKey]bool, error) {
+	return nil, errors.New("snapshots should be preferred over existence checks")
+}
+
+func (m *mockBatchRepository) GetMany(ctx context.Context, keys []ItemKey) (map[ItemKey]Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.getManyCalls++
+	found := make(map[ItemKey]Item)
+	for _, key := range keys {
+		if item, ok := m.snapshots[key]; ok {
+			found[key] = item
+		}
+	}
+	return found, nil
+}
+
+func (m *mockBatchRepository) SaveMany(ctx context.Context, items []Item) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.saveManyCalls++
+	m.saved = append(m.saved, items...)
+	return nil
+}
+
+func TestWatcherService_Run_BatchRepository(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	known := Item{ID: "1", Provider: "MockProvider", Title: "Known", Url: "https://test.com/1"}
+	items := []Item{
+		known,
+		{ID: "2", Title: "New", Url: "https://test.com/2"},
+		{ID: "3", Title: "Filtered", Price: 5000, Url: "https://test.com/3"},
+		{ID: "4", Title: "Other new", Url: "https://test.com/4"},
+	}
+
+	mockRepo := &mockBatchRepository{mockSnapshotRepository: mockSnapshotRepository{snapshots: map[ItemKey]Item{known.Key(): known}}}
+	mockNotif := &mockNotifier{}
+
+	svc := NewWatcherService([]Provider{&mockProvider{items: items}}, mockRepo, mockNotif, logger,
+		WithFilters(map[string]Filter{"MockProvider": {MaxPrice: 1000}}),
+	)
+
+	if _, err := svc.Run(context.Background()); err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+
+	if mockRepo.getManyCalls != 1 || mockRepo.saveManyCalls != 1 {
+		t.Errorf("Expected 1 GetMany and 1 SaveMany call, got %d and %d", mockRepo.getManyCalls, mockRepo.saveManyCalls)
+	}
+	if len(mockRepo.saved) != 3 {
+		t.Errorf("Expected the filtered and the 2 new items to be saved, got %d", len(mockRepo.saved))
+	}
+	if len(mockNotif.sent) != 2 {
+		t.Errorf("Notifier.Send() called %d times, want 2", len(mockNotif.sent))
+	}
+}
+
+// mockCountingRepository counts the items of a provider among the known and saved ones.
+type mockCountingRepository struct {
+	mockRepository
+	countErr error // Simulate a failure to count
+}
+
+func (m *mockCountingRepository) CountItems(ctx context.Context, provider string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.countErr != nil {
+		return 0, m.countErr
+	}
+	count := 0
+	for key := range m.exists {
+		if key.Provider == provider {
+			count++
+		}
+	}
+	for _, item := range m.saved {
+		if item.Provider == provider {
+			count++
+		}
+	}
+	return count, nil
+}
+
+type mockSummaryNotifier struct {
+	mockNotifier
+	summaries []BootstrapSummary
+}
+
+func (m *mockSummaryNotifier) SendSummary(ctx context.Context, summary BootstrapSummary) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.summaries = append(m.summaries, summary)
+	return nil
+}
+
+func TestWatcherService_Run_Bootstrap(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name          string
+		mode          BootstrapMode
+		summary       bool
+		known         map[ItemKey]bool
+		wantSent      int
+		wantSummaries int
+		wantSaved     int
+		bootstrapped  bool
+	}{
+		{
+			name:          "Empty repository is seeded silently with a summary",
+			mode:          BootstrapAuto,
+			summary:       true,
+			wantSent:      0,
+			wantSummaries: 1,
+			wantSaved:     2,
+			bootstrapped:  true,
+		},
+		{
+			name:         "Empty repository is seeded without summary",
+			mode:         BootstrapAuto,
+			wantSaved:    2,
+			bootstrapped: true,
+		},
+		{
+			name:      "Repository holding items runs normally",
+			mode:      BootstrapAuto,
+			summary:   true,
+			known:     map[ItemKey]bool{{Provider: "MockProvider", ID: "1"}: true},
+			wantSent:  1,
+			wantSaved: 1,
+		},
+		{
+			name:          "Forced bootstrap ignores the repository content",
+			mode:          BootstrapForced,
+			summary:       true,
+			known:         map[ItemKey]bool{{Provider: "MockProvider", ID: "1"}: true},
+			wantSummaries: 1,
+			wantSaved:     2,
+			bootstrapped:  true,
+		},
+		{
+			name:      "Disabled bootstrap notifies everything",
+			mode:      BootstrapOff,
+			summary:   true,
+			wantSent:  2,
+			wantSaved: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &mockProvider{items: []Item{
+				{ID: "1", Title: "First", Url: "https://test.com/1"},
+				{ID: "2", Title: "Second", Url: "https://test.com/2"},
+			}}
+			mockRepo := &mockCountingRepository{mockRepository: mockRepository{exists: tt.known}}
+			mockNotif := &mockSummaryNotifier{}
+
+			svc := NewWatcherService([]Provider{provider}, mockRepo, mockNotif, logger, WithBootstrap(tt.mode, tt.summary))
+
+			report, err := svc.Run(context.Background())
+			if err != nil {
+				t.Fatalf("Run() returned an unexpected error: %v", err)
+			}
+
+			if len(mockNotif.sent) != tt.wantSent {
+				t.Errorf("Notifier.Send() called %d times, want %d", len(mockNotif.sent), tt.wantSent)
+			}
+			if len(mockNotif.summaries) != tt.wantSummaries {
+				t.Errorf("Notifier.SendSummary() called %d times, want %d", len(mockNotif.summaries), tt.wantSummaries)
+			}
+			if len(mockRepo.saved) != tt.wantSaved {
+				t.Errorf("Repo.Save() called %d times, want %d", len(mockRepo.saved), tt.wantSaved)
+			}
+			if report.Providers[0].Bootstrapped != tt.bootstrapped {
+				t.Errorf("Report.Bootstrapped = %v, want %v", report.Providers[0].Bootstrapped, tt.bootstrapped)
+			}
+		})
+	}
+
+	// Once seeded, the service switches to normal operation
+	t.Run("Switches to normal operation after the bootstrap", func(t *testing.T) {
+		provider := &mockProvider{items: []Item{{ID: "1", Title: "First", Url: "https://test.com/1"}}}
+		mockRepo := &mockCountingRepository{mockRepository: mockRepository{exists: map[ItemKey]bool{}}}
+		mockNotif := &mockSummaryNotifier{}
+
+		svc := NewWatcherService([]Provider{provider}, mockRepo, mockNotif, logger, WithBootstrap(BootstrapForced, false))
+
+		if _, err := svc.Run(context.Background()); err != nil {
+			t.Fatalf("Run() returned an unexpected error: %v", err)
+		}
+
+		provider.items = append(provider.items, Item{ID: "2", Title: "Second", Url: "https://test.com/2"})
+		mockRepo.exists[ItemKey{Provider: "MockProvider", ID: "1"}] = true
+
+		report, err := svc.Run(context.Background())
+		if err != nil {
+			t.Fatalf("Run() returned an unexpected error: %v", err)
+		}
+		if report.Providers[0].Bootstrapped {
+			t.Error("The forced bootstrap should only apply to the first run")
+		}
+		if len(mockNotif.sent) != 1 || mockNotif.sent[0].ID != "2" {
+			t.Errorf("Only the item published after the bootstrap should be notified, got %v", mockNotif.sent)
+		}
+	})
+
+	// Without the count, an empty repository cannot be told apart: notifying everything would flood the channels
+	t.Run("Skips the run when items cannot be counted", func(t *testing.T) {
+		provider := &mockProvider{items: []Item{{ID: "1", Title: "First", Url: "https://test.com/1"}}}
+		mockRepo := &mockCountingRepository{
+			mockRepository: mockRepository{exists: map[ItemKey]bool{}},
+			countErr:       errors.New("connection refused"),
+		}
+		mockNotif := &mockSummaryNotifier{}
+
+		svc := NewWatcherService([]Provider{provider}, mockRepo, mockNotif, logger, WithBootstrap(BootstrapAuto, true))
+
+		report, err := svc.Run(context.Background())
+		if err == nil || report.Providers[0].Err == nil {
+			t.Fatal("Run() should report the failure to count items")
+		}
+		if report.Providers[0].Fetched != 0 || len(mockRepo.saved) != 0 || len(mockNotif.sent) != 0 || len(mockNotif.summaries) != 0 {
+			t.Errorf("Nothing should be fetched, saved nor notified, got report %+v", report.Providers[0])
+		}
+	})
+
+	// Summaries follow the subscriptions and the outbox like any other notification
+	t.Run("Routes the summary through the subscriptions", func(t *testing.T) {
+		provider := &mockProvider{items: []Item{{ID: "1", Title: "First", Url: "https://test.com/1"}}}
+		mockRepo := &mockCountingRepository{mockRepository: mockRepository{exists: map[ItemKey]bool{}}}
+		global, mine, night, other := &mockSummaryNotifier{}, &mockSummaryNotifier{}, &mockSummaryNotifier{}, &mockSummaryNotifier{}
+		outbox := &mockOutbox{}
+
+		// A two-hour window around the current time
+		hour := time.Now().UTC().Hour()
+		quiet := QuietHours{Start: time.Duration(hour) * time.Hour, End: time.Duration((hour+2)%24) * time.Hour, AllowUrgent: true}
+
+		svc := NewWatcherService([]Provider{provider}, mockRepo, global, logger,
+			WithBootstrap(BootstrapAuto, true),
+			WithOutbox(outbox, RetryPolicy{}),
+			WithSubscriptions(
+				// The filter rejects the item: the summary is still relevant to the subscription
+				Subscription{Name: "mine", Providers: []string{"MockProvider"}, Filter: Filter{MinPrice: 1000},
+					Channels: []Channel{{Name: "email", Notifier: mine}, {Name: "night", Notifier: night, Quiet: quiet}}},
+				Subscription{Name: "other", Providers: []string{"other"}, Channels: []Channel{{Name: "email", Notifier: other}}},
+			),
+		)
+
+		if _, err := svc.Run(context.Background()); err != nil {
+			t.Fatalf("Run() returned an unexpected error: %v", err)
+		}
+
+		if len(mine.summaries) != 1 || mine.summaries[0].Items != 1 {
+			t.Errorf("Subscription watching the provider received %v, want one summary of 1 item", mine.summaries)
+		}
+		if len(global.summaries) != 0 || len(other.summaries) != 0 {
+			t.Errorf("Summary sent outside the subscriptions: global %v, other %v", global.summaries, other.summaries)
+		}
+		pending := outbox.byStatus(DeliveryPending)
+		if len(night.summaries) != 0 || len(pending) != 1 || pending[0].Channel != "mine/night" || pending[0].Kind != DeliverySummary {
+			t.Errorf("Expected the summary to be held by quiet hours, got pending %v", pending)
+		}
+	})
+
+	// Seeding an incomplete scan would notify the missing listings afterwards
+	t.Run("Postpones the bootstrap of an incomplete scan", func(t *testing.T) {
+		provider := &mockProvider{
+			items: []Item{{ID: "1", Title: "First", Url: "https://test.com/1"}},
+			err:   &PartialFetchError{Pages: []PageError{{Page: 2, Err: errors.New("timeout")}}, Total: 2},
+		}
+		mockRepo := &mockCountingRepository{mockRepository: mockRepository{exists: map[ItemKey]bool{}}}
+		mockNotif := &mockSummaryNotifier{}
+
+		svc := NewWatcherService([]Provider{provider}, mockRepo, mockNotif, logger, WithBootstrap(BootstrapForced, true))
+
+		report, _ := svc.Run(context.Background())
+		if report.Providers[0].Bootstrapped || len(mockRepo.saved) != 0 || len(mockNotif.sent) != 0 {
+			t.Errorf("Nothing should be saved nor notified, got report %+v", report.Providers[0])
+		}
+
+		// The forced bootstrap is still pending for the next, complete, scan
+		provider.err = nil
+		report, err := svc.Run(context.Background())
+		if err != nil {
+			t.Fatalf("Run() returned an unexpected error: %v", err)
+		}
+		if !report.Providers[0].Bootstrapped || len(mockNotif.sent) != 0 {
+			t.Errorf("The complete scan should be bootstrapped, got report %+v", report.Providers[0])
+		}
+	})
+}
+
+func TestWatcherService_Run_Streaming(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	provider := &mockStreamProvider{yields: []streamYield{
+		{item: Item{ID: "1", Title: "First", Url: "https://test.com/1"}},
+		{item: Item{ID: "2", Title: "Second", Url: "https://test.com/2"}},
+		{err: PageError{Page: 2, Err: errors.New("api status 503")}},
+		{item: Item{ID: "3", Title: "Third", Url: "https://test.com/3"}},
+	}}
+	mockRepo := &mockPresenceRepository{mockRepository: mockRepository{exists: map[ItemKey]bool{}}}
+	mockNotif := &mockNotifier{}
+
+	// The first chunk must be notified before the stream goes on
+	var notifiedMidStream int
+	provider.onYield = func(i int) {
+		if i == 3 {
+			mockNotif.mu.Lock()
+			notifiedMidStream = len(mockNotif.sent)
+			mockNotif.mu.Unlock()
+		}
+	}
+
+	svc := NewWatcherService([]Provider{provider}, mockRepo, mockNotif, logger,
+		WithChunkSize(2),
+		WithRemovalDetection(3, false),
+	)
+
+	report, err := svc.Run(context.Background())
+
+	if err == nil {
+		t.Error("Run() should report the failed page")
+	}
+	if notifiedMidStream != 2 {
+		t.Errorf("%d items notified while streaming, want the 2 items of the first chunk", notifiedMidStream)
+	}
+
+	got := report.Providers[0]
+	if got.Fetched != 3 || got.Notified != 3 || got.Saved != 3 || got.FailedPages != 1 || !got.Degraded {
+		t.Errorf("Provider report = %+v, want 3 items processed and 1 failed page", got)
+	}
+	if len(mockRepo.reconciled) != 0 {
+		t.Error("Repo.Reconcile() should not run on an incomplete stream")
+	}
+}
+
+// mockEnrichingProvider completes its items with a full description, and records which ones it enriched.
+type mockEnrichingProvider struct {
+	mockProvider
+	mu       sync.Mutex
+	enriched []string
+	failing  map[string]bool
+}
+
+func (m *mockEnrichingProvider) Enrich(ctx context.Context, item Item) (Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.enriched = append(m.enriched, item.ID)
+	if m.failing[item.ID] {
+		return item, errors.New("detail page unavailable")
+	}
+	item.FullDescription = "Full description of " + item.Title
+	item.Images = []string{"https://test.com/" + item.ID + ".jpg"}
+	item.ID = "tampered" // The service keeps the identity
+	return item, nil
+}
+
+func TestWatcherService_Run_Enrichment(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	unchanged := Item{ID: "1", Provider: "MockProvider", Title: "Unchanged", Price: 500, Url: "https://test.com/1"}
+	changed := Item{ID: "2", Provider: "MockProvider", Title: "Changed", Price: 600, Url: "https://test.com/2"}
+
+	provider := &mockEnrichingProvider{
+		mockProvider: mockProvider{items: []Item{
+			unchanged,
+			{ID: "2", Title: "Changed", Price: 550, Url: "https://test.com/2"},
+			{ID: "3", Title: "New", Price: 400, Url: "https://test.com/3"},
+			{ID: "4", Title: "New without details", Price: 400, Url: "https://test.com/4"},
+		}},
+		failing: map[string]bool{"4": true},
+	}
+	mockRepo := &mockSnapshotRepository{snapshots: map[ItemKey]Item{unchanged.Key(): unchanged, changed.Key(): changed}}
+	mockNotif := &mockChangeNotifier{}
+
+	svc := NewWatcherService([]Provider{provider}, mockRepo, mockNotif, logger, WithEnrichConcurrency(2))
+
+	report, err := svc.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+
+	// Only new and changed listings are worth a detail page
+	if len(provider.enriched) != 3 {
+		t.Errorf("Enrich() called for %v, want the changed and the 2 new items", provider.enriched)
+	}
+	if report.Providers[0].Enriched != 2 {
+		t.Errorf("Report.Enriched = %d, want 2", report.Providers[0].Enriched)
+	}
+
+	// A failed enrichment does not prevent the notification
+	if len(mockNotif.sent) != 2 {
+		t.Fatalf("Notifier.Send() called %d times, want 2", len(mockNotif.sent))
+	}
+	for _, item := range mockNotif.sent {
+		if item.ID == "3" && (item.FullDescription == "" || len(item.Images) != 1) {
+			t.Errorf("New item should be notified with its details, got %+v", item)
+		}
+		if item.ID == "tampered" {
+			t.Error("Enrichment should not change the identity of an item")
+		}
+	}
+	if len(mockNotif.changes) != 1 || mockNotif.changes[0].Item.FullDescription == "" {
+		t.Errorf("Changed item should be notified with its details, got %+v", mockNotif.changes)
+	}
+}
+
+func TestWatcherService_Run_Subscriptions(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	young := Item{ID: "1", Title: "Rex", Url: "https://test.com/1", Animal: &AnimalAttributes{Species: "dog", AgeMonths: 18}}
+	old := Item{ID: "2", Title: "Max", Url: "https://test.com/2", Animal: &AnimalAttributes{Species: "dog", AgeMonths: 96}}
+	flat := Item{ID: "3", Title: "T2 Schiltigheim", Price: 700, Url: "https://test.com/3",
+		RealEstate: &RealEstateAttributes{Rooms: 2, City: "Schiltigheim"}}
+
+	pets := &mockProvider{name: "pets", items: []Item{young, old}}
+	flats := &mockProvider{name: "flats", items: []Item{flat}}
+
+	alice, bob, team := &mockNotifier{}, &mockNotifier{}, &mockNotifier{}
+	subscriptions := []Subscription{
+		{
+			Name:      "young-dogs",
+			Providers: []string{"pets"},
+			Filter:    Filter{Ranges: []RangeRule{{Field: "age_months", Max: 36}}},
+			Channels:  []Channel{{Name: "email", Notifier: alice}, {Name: "log", Notifier: team}},
+		},
+		{
+			Name:     "flats",
+			Filter:   Filter{Matches: []MatchRule{{Field: "city", Values: []string{"Schiltigheim"}}}},
+			Channels: []Channel{{Name: "email", Notifier: bob}, {Name: "log", Notifier: team}},
+		},
+	}
+
+	t.Run("Routes items to the matching subscriptions", func(t *testing.T) {
+		mockRepo := &mockRepository{exists: map[ItemKey]bool{}}
+		svc := NewWatcherService([]Provider{pets, flats}, mockRepo, &mockNotifier{}, logger, WithSubscriptions(subscriptions...))
+
+		report, err := svc.Run(context.Background())
+		if err != nil {
+			t.Fatalf("Run() returned an unexpected error: %v", err)
+		}
+
+		if len(alice.sent) != 1 || alice.sent[0].ID != "1" {
+			t.Errorf("young-dogs subscriber received %v, want the young dog only", alice.sent)
+		}
+		if len(bob.sent) != 1 || bob.sent[0].ID != "3" {
+			t.Errorf("flats subscriber received %v, want the flat only", bob.sent)
+		}
+		if len(team.sent) != 2 {
+			t.Errorf("Shared log channel received %d items, want one per subscription match", len(team.sent))
+		}
+
+		// The old dog matches no subscription: marked as seen silently
+		if report.Providers[0].Filtered != 1 || report.Providers[0].Notified != 1 {
+			t.Errorf("Pets report = %+v, want 1 filtered and 1 notified", report.Providers[0])
+		}
+		if len(mockRepo.saved) != 3 {
+			t.Errorf("Repo.Save() called %d times, want 3", len(mockRepo.saved))
+		}
+	})
+
+	t.Run("Names outbox deliveries after the subscription", func(t *testing.T) {
+		outbox := &mockOutbox{}
+		svc := NewWatcherService([]Provider{pets, flats}, &mockRepository{exists: map[ItemKey]bool{}}, &mockNotifier{}, logger,
+			WithSubscriptions(subscriptions...),
+			WithOutbox(outbox, RetryPolicy{}),
+		)
+
+		if _, err := svc.Run(context.Background()); err != nil {
+			t.Fatalf("Run() returned an unexpected error: %v", err)
+		}
+
+		channels := make(map[string]bool)
+		for _, d := range outbox.byStatus(DeliverySent) {
+			channels[d.Channel] = true
+		}
+		for _, want := range []string{"young-dogs/email", "young-dogs/log", "flats/email", "flats/log"} {
+			if !channels[want] {
+				t.Errorf("No delivery sent through %q, got %v", want, channels)
+			}
+		}
+		if len(outbox.byStatus(DeliverySent)) != 4 {
+			t.Errorf("Expected 4 sent deliveries, got %d", len(outbox.byStatus(DeliverySent)))
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package core
+
+import "slices"
+
+// Subscription is a saved search: new and updated items matching it are routed to its own channels,
+// so each subscriber only hears about what they are hunting.
+type Subscription struct {
+	Name      string
+	Providers []string // Names of the providers watched, all of them when empty
+	Filter    Filter
+	Channels  []Channel
+}
+
+// Matches reports whether an item is relevant to the subscription.
+func (sub Subscription) Matches(item Item) bool {
+	if !sub.Watches(item.Provider) {
+		return false
+	}
+	ok, _ := sub.Filter.Match(item)
+	return ok
+}
+
+// Watches reports whether the subscription follows a provider, whatever its filter.
+func (sub Subscription) Watches(provider string) bool {
+	return len(sub.Providers) == 0 || slices.Contains(sub.Providers, provider)
+}
+
+// target is a channel of a subscription an event is routed to.
+type target struct {
+	key     string // Channel name in the outbox, see channelKey
+	channel Channel
+}
+
+// channelKey names a channel of a subscription in the outbox: "subscription/channel".
+// The unnamed default subscription keeps the bare channel names used before subscriptions existed,
+// so pending deliveries survive the upgrade.
+func channelKey(subscription, channel string) string {
+	if subscription == "" {
+		return channel
+	}
+	return subscription + "/" + channel
+}
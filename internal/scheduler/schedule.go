@@ -0,0 +1,196 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule tells when a job runs.
+type Schedule interface {
+	// Next returns the first run time strictly after the given time.
+	Next(after time.Time) time.Time
+}
+
+// Parse reads a schedule written "@every <duration>", as a standard 5-field cron expression
+// ("minute hour day-of-month month day-of-week"), or as one of the shortcuts @hourly, @daily and @weekly.
+// Cron expressions are evaluated in the timezone of the times given to Next.
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if interval, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(interval))
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("invalid schedule %q: interval must be positive", spec)
+		}
+		return Every(d), nil
+	}
+
+	switch spec {
+	case "@hourly":
+		spec = "0 * * * *"
+	case "@daily":
+		spec = "0 0 * * *"
+	case "@weekly":
+		spec = "0 0 * * 0"
+	}
+
+	cron, err := ParseCron(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+	}
+	return cron, nil
+}
+
+// Every runs a job at a fixed interval.
+type Every time.Duration
+
+func (e Every) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(e))
+}
+
+// Cron runs a job at the times matching a cron expression, to the minute.
+type Cron struct {
+	minute, hour, dom, month, dow uint64 // Bit sets of the accepted values
+	anyDay                        bool   // Neither day-of-month nor day-of-week is restricted
+	domOnly, dowOnly              bool   // Only one of them is restricted
+}
+
+// field describes the values accepted by a cron field.
+type field struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day-of-month", 1, 31},
+	{"month", 1, 12},
+	{"day-of-week", 0, 7}, // 0 and 7 are both Sunday
+}
+
+// ParseCron reads a 5-field cron expression. Each field accepts "*", values, ranges "1-5",
+// steps "*/15" or "8-18/2", and comma-separated lists of them.
+func ParseCron(expr string) (Cron, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return Cron{}, fmt.Errorf("want %d fields, got %d", len(cronFields), len(parts))
+	}
+
+	sets := make([]uint64, len(parts))
+	for i, part := range parts {
+		set, err := parseField(part, cronFields[i])
+		if err != nil {
+			return Cron{}, err
+		}
+		sets[i] = set
+	}
+
+	// Sunday may be written 7
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+
+	domAny, dowAny := parts[2] == "*", parts[4] == "*"
+	return Cron{
+		minute:  sets[0],
+		hour:    sets[1],
+		dom:     sets[2],
+		month:   sets[3],
+		dow:     sets[4],
+		anyDay:  domAny && dowAny,
+		domOnly: !domAny && dowAny,
+		dowOnly: domAny && !dowAny,
+	}, nil
+}
+
+func parseField(spec string, f field) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(spec, ",") {
+		rangeSpec, stepSpec, hasStep := strings.Cut(item, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepSpec)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid %s step %q", f.name, item)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		if rangeSpec != "*" {
+			from, to, isRange := strings.Cut(rangeSpec, "-")
+			var err error
+			if lo, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid %s %q", f.name, item)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("invalid %s %q", f.name, item)
+				}
+			} else if hasStep {
+				hi = f.max // "5/15" means from 5 to the end
+			}
+		}
+		if lo < f.min || hi > f.max || lo > hi {
+			return 0, fmt.Errorf("%s %q out of range %d-%d", f.name, item, f.min, f.max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// Next returns the first matching minute strictly after the given time.
+// It returns the zero time when nothing matches within five years, e.g. for February 30th.
+func (c Cron) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// matchesDay follows the cron convention: when both day fields are restricted,
+// a day matching either of them is accepted.
+func (c Cron) matchesDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.anyDay:
+		return true
+	case c.domOnly:
+		return dom
+	case c.dowOnly:
+		return dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,59 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	from := time.Date(2024, time.March, 15, 10, 7, 30, 0, time.UTC) // A Friday
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"@every 15m", from.Add(15 * time.Minute)},
+		{"@hourly", time.Date(2024, time.March, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, time.March, 16, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, time.March, 17, 0, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, time.March, 15, 10, 15, 0, 0, time.UTC)},
+		{"7 * * * *", time.Date(2024, time.March, 15, 11, 7, 0, 0, time.UTC)},
+		{"0 8-18/2 * * *", time.Date(2024, time.March, 15, 12, 0, 0, 0, time.UTC)},
+		{"30 9 * * 1-5", time.Date(2024, time.March, 18, 9, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, time.March, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1,20 2 *", time.Date(2025, time.February, 1, 12, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either one matches
+		{"0 0 1 * 6", time.Date(2024, time.March, 16, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse() returned an unexpected error: %v", err)
+			}
+			if got := schedule.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "@every", "@every -5m", "@monthly", "* * * *", "60 * * * *", "* 24 * * *", "0 0 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) should fail", spec)
+		}
+	}
+}
+
+func TestCron_NextImpossible(t *testing.T) {
+	cron, err := ParseCron("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("ParseCron() returned an unexpected error: %v", err)
+	}
+	if next := cron.Next(time.Now()); !next.IsZero() {
+		t.Errorf("Next() = %v, want zero for February 30th", next)
+	}
+}
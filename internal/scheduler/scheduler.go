@@ -0,0 +1,112 @@
+// Package scheduler runs jobs on their own schedule, one run at a time per job.
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Job is a task run on a schedule.
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Jitter   time.Duration // Upper bound of the random delay added to each scheduled time, 0 disables it
+	Timeout  time.Duration // Maximum duration of a run, 0 disables it
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler runs each job once at startup, then at the times of its schedule.
+// Runs of a job never overlap: a run lasting past the next scheduled time skips it.
+type Scheduler struct {
+	jobs   []Job
+	logger *slog.Logger
+	now    func() time.Time
+	jitter func(max time.Duration) time.Duration
+}
+
+// New creates a scheduler for the given jobs.
+func New(logger *slog.Logger, jobs ...Job) *Scheduler {
+	return &Scheduler{
+		jobs:   jobs,
+		logger: logger,
+		now:    time.Now,
+		jitter: func(max time.Duration) time.Duration {
+			if max <= 0 {
+				return 0
+			}
+			return rand.N(max)
+		},
+	}
+}
+
+// Run blocks until the context is cancelled, then waits for the runs in progress.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, job := range s.jobs {
+		wg.Add(1)
+		go func(job Job) {
+			defer wg.Done()
+			s.loop(ctx, job)
+		}(job)
+	}
+	wg.Wait()
+}
+
+// loop runs a job until the context is cancelled. Running it in a single goroutine
+// is what prevents overlapping runs.
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	logger := s.logger.With("job", job.Name)
+
+	for {
+		s.runOnce(ctx, logger, job)
+
+		next := s.next(job, s.now())
+		if next.IsZero() {
+			logger.Warn("schedule has no next run, job stopped")
+			return
+		}
+		logger.Info("next run scheduled", "next_run_at", next)
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// next returns the next jittered run time of a job after now.
+func (s *Scheduler) next(job Job, now time.Time) time.Time {
+	next := job.Schedule.Next(now)
+	if next.IsZero() {
+		return next
+	}
+	return next.Add(s.jitter(job.Jitter))
+}
+
+// runOnce runs a job within its timeout, logging the outcome.
+func (s *Scheduler) runOnce(ctx context.Context, logger *slog.Logger, job Job) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	runCtx := ctx
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+
+	logger.Info("starting scheduled run")
+	start := s.now()
+	if err := job.Run(runCtx); err != nil {
+		logger.Error("scheduled run failed", "error", err, "duration", s.now().Sub(start))
+		return
+	}
+	logger.Info("scheduled run finished", "duration", s.now().Sub(start))
+}
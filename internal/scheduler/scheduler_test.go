@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScheduler_Run(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("Runs at startup then on schedule, never overlapping", func(t *testing.T) {
+		var running, overlaps, runs atomic.Int32
+		ctx, cancel := context.WithCancel(context.Background())
+
+		job := Job{
+			Name:     "slow",
+			Schedule: Every(time.Millisecond), // Shorter than a run
+			Run: func(ctx context.Context) error {
+				if running.Add(1) > 1 {
+					overlaps.Add(1)
+				}
+				time.Sleep(5 * time.Millisecond)
+				running.Add(-1)
+				if runs.Add(1) == 3 {
+					cancel()
+				}
+				return nil
+			},
+		}
+
+		done := make(chan struct{})
+		go func() {
+			New(logger, job).Run(ctx)
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Run() did not return after cancellation")
+		}
+		if runs.Load() != 3 {
+			t.Errorf("Job ran %d times, want 3", runs.Load())
+		}
+		if overlaps.Load() != 0 {
+			t.Errorf("Runs overlapped %d times", overlaps.Load())
+		}
+	})
+
+	t.Run("Each run is bounded by the job timeout", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var once sync.Once
+		errs := make(chan error, 1)
+		job := Job{
+			Name:     "stuck",
+			Schedule: Every(time.Hour),
+			Timeout:  10 * time.Millisecond,
+			Run: func(ctx context.Context) error {
+				<-ctx.Done()
+				once.Do(func() { errs <- ctx.Err() })
+				return ctx.Err()
+			},
+		}
+
+		go New(logger, job).Run(ctx)
+
+		select {
+		case err := <-errs:
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Run context error = %v, want deadline exceeded", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("The job timeout was not applied")
+		}
+	})
+}
+
+func TestScheduler_Next(t *testing.T) {
+	s := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	now := time.Date(2024, time.March, 15, 10, 0, 0, 0, time.UTC)
+
+	if next := s.next(Job{Schedule: Every(time.Hour)}, now); !next.Equal(now.Add(time.Hour)) {
+		t.Errorf("next() = %v, want no jitter by default", next)
+	}
+
+	for range 100 {
+		next := s.next(Job{Schedule: Every(time.Hour), Jitter: time.Minute}, now)
+		if next.Before(now.Add(time.Hour)) || !next.Before(now.Add(time.Hour+time.Minute)) {
+			t.Fatalf("next() = %v, want within the jitter after the scheduled time", next)
+		}
+	}
+}